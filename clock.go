@@ -0,0 +1,17 @@
+package buzza
+
+import "time"
+
+// Source of current time. Time-dependent logic takes it instead of calling time.Now,
+// so tests can move time forward instead of sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type RealClock struct{}
+
+var _ Clock = RealClock{}
+
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
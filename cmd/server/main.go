@@ -2,80 +2,205 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
 	"flag"
+	"fmt"
 	"log/syslog"
+	"net"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/buzkaaclicker/buzza"
 	"github.com/buzkaaclicker/buzza/discord"
 	"github.com/buzkaaclicker/buzza/persistent"
 	"github.com/buzkaaclicker/buzza/transport/rest"
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
 	. "github.com/klauspost/cpuid/v2"
 	"github.com/sirupsen/logrus"
 	logrusys "github.com/sirupsen/logrus/hooks/syslog"
 	"github.com/tidwall/buntdb"
 	"github.com/uptrace/bun"
 	_ "github.com/uptrace/bun/driver/pgdriver"
-	"github.com/uptrace/bun/extra/bundebug"
 )
 
-func listenAndServe(
-	ctx context.Context,
-	bdb *buntdb.DB,
-	db *bun.DB,
-	discordConfig discordConfig,
-	debug bool,
-) func() error {
-	userStore := &persistent.UserStore{DB: db}
-	profileStore := &persistent.ProfileStore{DB: db}
-	activityStore := &persistent.ActivityStore{DB: db}
-	sessionStore := &persistent.SessionStore{Buntdb: bdb, ActivityStore: activityStore}
+// Injected at build time e.g.
+// go build -ldflags "-X main.gitCommit=$(git rev-parse --short HEAD) -X main.buildTime=$(date -u +%FT%TZ)"
+var (
+	gitCommit = "unknown"
+	buildTime = "unknown"
+)
+
+var seed = flag.Bool("seed", false, "load sample programs into empty database, debug only")
+
+const apiMountPath = "/api/"
+
+type serverConfig struct {
+	bdb     *buntdb.DB
+	db      *bun.DB
+	discord discordConfig
+	debug   bool
+	addr    string
+	// Listener for operational endpoints, they are served by main listener when empty.
+	metricsAddr string
+	drainDelay  time.Duration
+	// Admin shutdown endpoint is installed only when set.
+	adminShutdown  func()
+	trustedProxies []*net.IPNet
+	// Routes scheduled for removal. Clients using them receive deprecation headers.
+	deprecatedRoutes []rest.DeprecatedRoute
+}
+
+func listenAndServe(ctx context.Context, config serverConfig) func() error {
+	userStore := &persistent.UserStore{DB: config.db}
+	profileStore := &persistent.ProfileStore{DB: config.db}
+	activityStore := &persistent.ActivityStore{DB: config.db}
+	sessionStore := &persistent.SessionStore{Buntdb: config.bdb, ActivityStore: activityStore, Clock: buzza.RealClock{}}
 	sessionStore.CreateIndexes()
 
 	authController := rest.AuthController{
-		CreateDiscordOAuthUrl: discordConfig.oauthUrlFactory,
-		ExchangeAccessToken:   discordConfig.accessTokenExchanger,
-		UserMeProvider:        discord.RestUserMeProvider,
-		GuildMemberAdd:        discordConfig.guildMemberAdd,
+		CreateDiscordOAuthUrl: config.discord.oauthUrlFactory,
+		ExchangeAccessToken:   config.discord.accessTokenExchanger,
+		UserMeProvider:        discord.RestUserMeProvider(config.discord.httpClient),
+		GuildMemberAdd:        config.discord.guildMemberAdd,
 		SessionStore:          sessionStore,
 		UserStore:             userStore,
 	}
 
-	programStore := &persistent.ProgramStore{DB: db}
-	programController := rest.ProgramController{Store: programStore}
+	programStore := &persistent.ProgramStore{DB: config.db}
+	programController := rest.ProgramController{
+		Store:             programStore,
+		DisableStaleServe: os.Getenv("DISABLE_STALE_SERVE") == "true",
+		SigningKey:        programSigningKeyFromEnv(),
+		Clock:             buzza.RealClock{},
+	}
+	programAdminController := rest.ProgramAdminController{
+		Store: programStore,
+		Mode:  promotionModeFromEnv(),
+	}
 	profileController := rest.ProfileController{Store: profileStore}
+	outboundMetricsController := rest.OutboundMetricsController{Discord: config.discord.httpClient}
+	cacheMetricsController := rest.CacheMetricsController{Programs: &programController}
+	featureFlagController := rest.FeatureFlagController{
+		Store: &persistent.FeatureFlagStore{DB: config.db},
+		Clock: buzza.RealClock{},
+	}
 	activityController := rest.ActivityController{Store: activityStore}
 	sessionController := rest.SessionController{Store: sessionStore}
+	aboutController := rest.AboutController{
+		Build: rest.BuildInfo{
+			Commit:    gitCommit,
+			BuildTime: buildTime,
+			GoVersion: runtime.Version(),
+		},
+	}
+	healthController := rest.HealthController{
+		Checks: []rest.HealthCheck{
+			{Name: "postgres", Critical: true, Check: config.db.PingContext},
+			{Name: "schema", Critical: true, Check: func(ctx context.Context) error {
+				return persistent.CheckSchema(ctx, config.db)
+			}},
+			{Name: "buntdb", Critical: true, Check: func(ctx context.Context) error {
+				return config.bdb.View(func(tx *buntdb.Tx) error {
+					_, err := tx.Len()
+					return err
+				})
+			}},
+		},
+	}
+	maintenanceController := rest.MaintenanceController{RetryAfter: time.Minute}
+	maintenanceController.SetEnabled(os.Getenv("MAINTENANCE_MODE") == "true")
 
-	server := fiber.New()
-	server.Use(rest.LogHandler())
+	// static files and unknown paths are served by the outer server,
+	// so it needs the same error handler to reply with json errors.
+	server := fiber.New(fiber.Config{ErrorHandler: rest.ErrorHandler})
+	server.Use(rest.RecoverHandler(config.debug))
+	server.Use(rest.ClientIPHandler(config.trustedProxies))
+	server.Use(rest.RequestIdHandler())
+	concurrencyLimiter := rest.NewConcurrencyLimiter(maxConcurrentRequestsFromEnv(), time.Second)
+	operationalPaths := rest.OperationalPaths(apiMountPath)
+	server.Use(rest.ExceptPaths(concurrencyLimiter.Handler(), operationalPaths...))
+	if os.Getenv("LOG_OPERATIONAL_REQUESTS") == "true" {
+		server.Use(rest.LogHandler())
+	} else {
+		server.Use(rest.ExceptPaths(rest.LogHandler(), operationalPaths...))
+	}
+	if routes := os.Getenv("BODY_LOG_ROUTES"); config.debug && routes != "" {
+		server.Use(rest.BodyLogHandler(rest.BodyLogConfig{
+			Routes:       strings.Split(routes, ","),
+			MaxSize:      4096,
+			RedactFields: []string{"code", "accessToken", "refreshToken", "token", "email"},
+		}))
+	}
 
+	const apiTimeout = 10 * time.Second
 	api := fiber.New(fiber.Config{
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		ReadTimeout:  apiTimeout,
+		WriteTimeout: apiTimeout,
 		ErrorHandler: rest.ErrorHandler,
 	})
 
 	allowOrigins := "https://buzkaaclicker.pl"
-	if debug {
+	if config.debug {
 		allowOrigins += ", http://test.buzkaaclicker.pl:3000"
 	}
-	api.Use(cors.New(cors.Config{AllowOrigins: allowOrigins}))
+	api.Use(rest.CORSHandler(allowOrigins, corsMaxAgeFromEnv()))
+	api.Use(rest.DeprecationHandler(config.deprecatedRoutes...))
+	api.Use(rest.RequireJSONHandler())
+	api.Use(rest.RequestTimeoutHandler(apiTimeout))
+	if os.Getenv("DISABLE_PRETTY_JSON") != "true" {
+		api.Use(rest.PrettyJSONHandler())
+	}
 
 	requestAuthorizer := rest.RequestAuthorizer(sessionStore, userStore)
-	api.Get("/status", monitor.New())
+	maintenanceController.InstallTo(requestAuthorizer, api)
+	if config.adminShutdown != nil {
+		// draining is how maintenance usually ends, so it can't be rejected by it
+		shutdownController := rest.ShutdownController{Shutdown: config.adminShutdown}
+		shutdownController.InstallTo(requestAuthorizer, api)
+	}
+	api.Use(maintenanceController.Handler())
+
+	// Operational endpoints leak internals, serve them on separate internal listener if configured.
+	var internal *fiber.App
+	operational := api
+	if config.metricsAddr != "" {
+		internal = fiber.New(fiber.Config{
+			ErrorHandler:          rest.ErrorHandler,
+			DisableStartupMessage: true,
+		})
+		internal.Use(pprof.New())
+		operational = internal
+	}
+	operational.Get("/status", monitor.New())
+	concurrencyLimiter.InstallTo(operational)
+	healthController.InstallTo(operational)
+	outboundMetricsController.InstallTo(operational)
+	cacheMetricsController.InstallTo(operational)
+
+	aboutController.InstallTo(api)
 	authController.InstallTo(api)
 	programController.InstallTo(api)
+	programAdminController.InstallTo(requestAuthorizer, api)
 	profileController.InstallTo(api)
 	activityController.InstallTo(requestAuthorizer, api)
 	sessionController.InstallTo(requestAuthorizer, api)
+	featureFlagController.InstallTo(requestAuthorizer, api)
+	if internal == nil {
+		// profiles expose memory contents, without internal listener only admins may take them
+		pprofController := rest.PprofController{}
+		pprofController.InstallTo(requestAuthorizer, server)
+	}
 
-	server.Mount("/api/", api)
+	server.Mount(apiMountPath, api)
 
 	server.Static("/", "./www/", fiber.Static{
 		Browse: false,
@@ -84,18 +209,54 @@ func listenAndServe(
 
 	server.Use(rest.NotFoundHandler)
 
-	var addr string
-	if debug {
-		addr = "127.0.0.1:2137"
-	} else {
-		addr = ":2137"
+	go func() {
+		if err := server.Listen(config.addr); err != nil {
+			logrus.WithError(err).WithField("addr", config.addr).Errorln(listenErrorMessage(config.addr, err))
+		}
+	}()
+	if internal != nil {
+		go func() {
+			logrus.WithField("addr", config.metricsAddr).Infoln("Listening for internal requests.")
+			if err := internal.Listen(config.metricsAddr); err != nil {
+				logrus.WithError(err).WithField("addr", config.metricsAddr).Errorln(listenErrorMessage(config.metricsAddr, err))
+			}
+		}()
 	}
-	go server.Listen(addr)
+	healthController.SetReady(true)
 
 	return func() error {
-		return nil
-		// return server.Shutdown()
+		// Stop advertising readiness first, so load balancers stop routing
+		// new requests before we stop accepting connections.
+		logrus.Infoln("Marking backend as not ready.")
+		healthController.SetReady(false)
+
+		logrus.WithField("delay", config.drainDelay).Infoln("Waiting for load balancers to drain.")
+		time.Sleep(config.drainDelay)
+
+		logrus.Infoln("Shutting down http server.")
+		err := server.Shutdown()
+		if internal != nil {
+			// after public server, so health stays observable while draining
+			logrus.Infoln("Shutting down internal http server.")
+			if internalErr := internal.Shutdown(); internalErr != nil && err == nil {
+				err = internalErr
+			}
+		}
+		return err
+	}
+}
+
+// Bare "permission denied" on privileged port is cryptic, tell how to fix it.
+func listenErrorMessage(addr string, err error) string {
+	if errors.Is(err, syscall.EACCES) {
+		_, portStr, splitErr := net.SplitHostPort(addr)
+		port, atoiErr := strconv.Atoi(portStr)
+		if splitErr == nil && atoiErr == nil && port > 0 && port < 1024 {
+			return fmt.Sprintf("Could not listen on privileged port %d. Grant CAP_NET_BIND_SERVICE "+
+				"(setcap cap_net_bind_service=+ep) or listen on port above 1023 behind proxy.", port)
+		}
 	}
+	return "Could not listen."
 }
 
 func setupLogger(verbose bool) {
@@ -119,6 +280,7 @@ type discordConfig struct {
 	clientId             string
 	clientSecret         string
 	redirectUri          string
+	httpClient           *discord.Client
 	oauthUrlFactory      discord.OAuthUrlFactory
 	accessTokenExchanger discord.AccessTokenExchanger
 	guildMemberAdd       discord.GuildMemberAdd
@@ -137,20 +299,161 @@ func discordConfigFromEnv() discordConfig {
 	redirectUri := requireEnv("DISCORD_AUTH_URI")
 	guildId := requireEnv("DISCORD_GUILD_ID")
 	botToken := requireEnv("DISCORD_BOT_TOKEN")
+	httpClient := discord.NewClient()
 	return discordConfig{
 		clientId,
 		clientSecret,
 		redirectUri,
+		httpClient,
 		discord.RestOAuthUrlFactory(clientId, redirectUri),
-		discord.RestAccessTokenExchanger(clientId, clientSecret, redirectUri),
-		discord.RestGuildMemberAdd(botToken, guildId),
+		discord.RestAccessTokenExchanger(httpClient, clientId, clientSecret, redirectUri),
+		discord.RestGuildMemberAdd(httpClient, botToken, guildId),
+	}
+}
+
+// Base64 encoded ed25519 seed. Signing is disabled when not set.
+func programSigningKeyFromEnv() ed25519.PrivateKey {
+	value := os.Getenv("PROGRAM_SIGNING_KEY")
+	if value == "" {
+		return nil
+	}
+	seed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		logrus.Fatalln("PROGRAM_SIGNING_KEY must be base64 encoded 32 byte ed25519 seed!")
+	}
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+func promotionModeFromEnv() buzza.PromotionMode {
+	switch mode := buzza.PromotionMode(os.Getenv("PROGRAM_PROMOTION_MODE")); mode {
+	case "":
+		return buzza.PromotionClone
+	case buzza.PromotionClone, buzza.PromotionMove:
+		return mode
+	default:
+		logrus.Fatalln("PROGRAM_PROMOTION_MODE must be either clone or move!")
+		return ""
+	}
+}
+
+func maxConcurrentRequestsFromEnv() int {
+	const defaultLimit = 256
+	value := os.Getenv("MAX_CONCURRENT_REQUESTS")
+	if value == "" {
+		return defaultLimit
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		logrus.Fatalln("MAX_CONCURRENT_REQUESTS must be positive integer!")
+	}
+	return limit
+}
+
+func corsMaxAgeFromEnv() time.Duration {
+	const defaultMaxAge = 5 * time.Minute
+	value := os.Getenv("CORS_MAX_AGE")
+	if value == "" {
+		return defaultMaxAge
+	}
+	maxAge, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithError(err).Fatalln("Invalid CORS_MAX_AGE.")
+	}
+	return maxAge
+}
+
+// Zero disables statement timeout.
+func statementTimeoutFromEnv() time.Duration {
+	const defaultTimeout = 30 * time.Second
+	value := os.Getenv("DB_STATEMENT_TIMEOUT")
+	if value == "" {
+		return defaultTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithError(err).Fatalln("Invalid DB_STATEMENT_TIMEOUT.")
+	}
+	return timeout
+}
+
+func slowQueryThresholdFromEnv() time.Duration {
+	const defaultThreshold = 200 * time.Millisecond
+	value := os.Getenv("DB_SLOW_QUERY_THRESHOLD")
+	if value == "" {
+		return defaultThreshold
+	}
+	threshold, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithError(err).Fatalln("Invalid DB_SLOW_QUERY_THRESHOLD.")
+	}
+	return threshold
+}
+
+// Disabled unless DB_POOL_WARM_INTERVAL is set.
+func poolWarmerFromEnv(pool persistent.Pool) *persistent.PoolWarmer {
+	value := os.Getenv("DB_POOL_WARM_INTERVAL")
+	if value == "" {
+		return nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		logrus.Fatalln("DB_POOL_WARM_INTERVAL must be positive duration!")
+	}
+	// database/sql keeps 2 idle connections by default
+	conns := 2
+	if value := os.Getenv("DB_POOL_WARM_CONNS"); value != "" {
+		conns, err = strconv.Atoi(value)
+		if err != nil || conns <= 0 {
+			logrus.Fatalln("DB_POOL_WARM_CONNS must be positive integer!")
+		}
+	}
+	return &persistent.PoolWarmer{Pool: pool, Conns: conns, Interval: interval}
+}
+
+func drainDelayFromEnv() time.Duration {
+	const defaultDelay = 5 * time.Second
+	value := os.Getenv("SHUTDOWN_DRAIN_DELAY")
+	if value == "" {
+		return defaultDelay
+	}
+	delay, err := time.ParseDuration(value)
+	if err != nil {
+		logrus.WithError(err).Fatalln("Invalid SHUTDOWN_DRAIN_DELAY.")
+	}
+	return delay
+}
+
+func seedFixtures(db *bun.DB, debug bool) {
+	if !debug {
+		logrus.Fatalln("Refusing to seed database outside of debug mode.")
+	}
+	logrus.Infoln("Seeding database.")
+	err := persistent.SeedFixtures(context.Background(), db)
+	switch {
+	case errors.Is(err, persistent.ErrDatabaseNotEmpty):
+		logrus.Warnln("Database not empty, skipping seed.")
+	case err != nil:
+		logrus.WithError(err).Fatalln("Could not seed database.")
 	}
 }
 
-func awaitInterruption() {
+// Blocks until interrupted or shutdown is requested.
+// Returned channel keeps receiving signals delivered after that.
+func awaitInterruption(shutdownRequested <-chan struct{}) <-chan os.Signal {
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	<-c
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-c:
+	case <-shutdownRequested:
+	}
+	return c
+}
+
+// Another signal during graceful shutdown means operator does not want to wait, exit immediately.
+func forceExitOnSignal(signals <-chan os.Signal, exit func(code int)) {
+	sig := <-signals
+	logrus.WithField("signal", sig).Warnln("Received another signal during shutdown, forcing exit.")
+	exit(1)
 }
 
 func main() {
@@ -173,27 +476,80 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatalln("Could not open buntdb.")
 	}
-	defer bdb.Close()
 
 	logrus.Infoln("Opening database.")
-	pg := persistent.PgOpen(context.Background(), pgDsn)
-	if debug {
-		pg.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
+	pg, err := persistent.PgOpen(context.Background(), pgDsn, persistent.PgOptions{
+		StatementTimeout:   statementTimeoutFromEnv(),
+		SlowQueryThreshold: slowQueryThresholdFromEnv(),
+	})
+	if err != nil {
+		logrus.WithError(err).Fatalln("Could not open pg database.")
+	}
+	if *seed {
+		seedFixtures(pg, debug)
+	}
+	warmerCtx, stopWarmer := context.WithCancel(context.Background())
+	defer stopWarmer()
+	if warmer := poolWarmerFromEnv(pg); warmer != nil {
+		pg.SetMaxIdleConns(warmer.Conns)
+		go warmer.Run(warmerCtx)
 	}
-	defer pg.DB.Close()
-	defer pg.Close()
 
 	discordConfig := discordConfigFromEnv()
+	drainDelay := drainDelayFromEnv()
+	trustedProxies, err := rest.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		logrus.WithError(err).Fatalln("Invalid TRUSTED_PROXIES.")
+	}
+	deprecatedRoutes, err := rest.ParseDeprecatedRoutes(os.Getenv("DEPRECATED_ROUTES"))
+	if err != nil {
+		logrus.WithError(err).Fatalln("Invalid DEPRECATED_ROUTES.")
+	}
+
+	shutdownRequested := make(chan struct{})
+	var adminShutdown func()
+	if os.Getenv("ENABLE_ADMIN_SHUTDOWN") == "true" {
+		adminShutdown = func() { close(shutdownRequested) }
+	}
+
+	addr := os.Getenv("LISTEN_ADDR")
+	if addr == "" && debug {
+		addr = "127.0.0.1:2137"
+	} else if addr == "" {
+		addr = ":2137"
+	}
+	metricsAddr := os.Getenv("METRICS_ADDR")
 
 	logrus.Infoln("Starting listening... To shut down use ^C")
-	shutdown := listenAndServe(context.Background(), bdb, pg, discordConfig, debug)
+	shutdown := listenAndServe(context.Background(), serverConfig{
+		bdb:              bdb,
+		db:               pg,
+		discord:          discordConfig,
+		debug:            debug,
+		addr:             addr,
+		metricsAddr:      metricsAddr,
+		drainDelay:       drainDelay,
+		adminShutdown:    adminShutdown,
+		trustedProxies:   trustedProxies,
+		deprecatedRoutes: deprecatedRoutes,
+	})
 
-	awaitInterruption()
+	signals := awaitInterruption(shutdownRequested)
+	go forceExitOnSignal(signals, os.Exit)
 
-	logrus.Infoln("Shutting down...")
+	logrus.Infoln("Shutting down... Press ^C again to force exit.")
 	err = shutdown()
 	if err != nil {
 		logrus.WithError(err).Warningln("Fiber shutdown failed.")
 	}
+
+	logrus.Infoln("Closing databases.")
+	stopWarmer()
+	if err := pg.Close(); err != nil {
+		logrus.WithError(err).Warningln("Could not close pg database.")
+	}
+	if err := bdb.Close(); err != nil {
+		logrus.WithError(err).Warningln("Could not close buntdb.")
+	}
 	logrus.Exit(0)
 }
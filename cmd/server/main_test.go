@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/buzkaaclicker/buzza/transport/rest"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/buntdb"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+func TestForceExitOnSignal(t *testing.T) {
+	assert := assert.New(t)
+
+	signals := make(chan os.Signal, 1)
+	exitCodes := make(chan int, 1)
+	go forceExitOnSignal(signals, func(code int) {
+		exitCodes <- code
+	})
+
+	select {
+	case <-exitCodes:
+		assert.Fail("exited without second signal")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	signals <- syscall.SIGINT
+	select {
+	case code := <-exitCodes:
+		assert.Equal(1, code)
+	case <-time.After(time.Second):
+		assert.Fail("second signal did not force exit")
+	}
+}
+
+func TestForceExitOnRealSignal(t *testing.T) {
+	assert := assert.New(t)
+
+	// keeps SIGINT from killing test binary until awaitInterruption subscribes
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, os.Interrupt)
+	defer signal.Stop(guard)
+
+	signalsCh := make(chan (<-chan os.Signal), 1)
+	go func() {
+		signalsCh <- awaitInterruption(make(chan struct{}))
+	}()
+
+	// subscription time is unknown, keep interrupting until first signal is noticed
+	var signals <-chan os.Signal
+	deadline := time.After(time.Second)
+	for signals == nil {
+		if !assert.NoError(syscall.Kill(os.Getpid(), syscall.SIGINT)) {
+			return
+		}
+		select {
+		case signals = <-signalsCh:
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			assert.Fail("first signal did not interrupt")
+			return
+		}
+	}
+	// drop interruptions sent while awaitInterruption was returning
+	time.Sleep(50 * time.Millisecond)
+	for len(signals) > 0 {
+		<-signals
+	}
+
+	exitCodes := make(chan int, 1)
+	go forceExitOnSignal(signals, func(code int) {
+		exitCodes <- code
+	})
+	select {
+	case <-exitCodes:
+		assert.Fail("exited without second signal")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if !assert.NoError(syscall.Kill(os.Getpid(), syscall.SIGINT)) {
+		return
+	}
+	select {
+	case code := <-exitCodes:
+		assert.Equal(1, code)
+	case <-time.After(time.Second):
+		assert.Fail("second signal did not force exit")
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func awaitListening(addr string) bool {
+	for i := 0; i < 100; i++ {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestListenAndServeInternalListener(t *testing.T) {
+	assert := assert.New(t)
+
+	bdb, err := buntdb.Open(":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer bdb.Close()
+	// never connected, operational endpoints under test don't touch database
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN("postgres://test@127.0.0.1:1/test"))), pgdialect.New())
+	defer db.Close()
+
+	addr, metricsAddr := freeAddr(t), freeAddr(t)
+	shutdown := listenAndServe(context.Background(), serverConfig{
+		bdb:         bdb,
+		db:          db,
+		debug:       true,
+		addr:        addr,
+		metricsAddr: metricsAddr,
+	})
+	defer func() {
+		assert.NoError(shutdown())
+	}()
+	if !assert.True(awaitListening(addr)) || !assert.True(awaitListening(metricsAddr)) {
+		return
+	}
+
+	status := func(url string) int {
+		resp, err := http.Get(url)
+		if !assert.NoError(err, url) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(http.StatusOK, status("http://"+metricsAddr+"/status"))
+	assert.Equal(http.StatusOK, status("http://"+metricsAddr+"/ready"))
+	assert.Equal(http.StatusOK, status("http://"+metricsAddr+"/debug/pprof/"))
+	assert.Equal(http.StatusNotFound, status("http://"+addr+"/api/status"))
+	assert.Equal(http.StatusNotFound, status("http://"+addr+"/api/ready"))
+	assert.Equal(http.StatusNotFound, status("http://"+addr+"/debug/pprof/"))
+}
+
+func TestListenAndServeWithoutInternalListener(t *testing.T) {
+	assert := assert.New(t)
+
+	bdb, err := buntdb.Open(":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer bdb.Close()
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN("postgres://test@127.0.0.1:1/test"))), pgdialect.New())
+	defer db.Close()
+
+	addr := freeAddr(t)
+	shutdown := listenAndServe(context.Background(), serverConfig{bdb: bdb, db: db, debug: true, addr: addr})
+	defer func() {
+		assert.NoError(shutdown())
+	}()
+	if !assert.True(awaitListening(addr)) {
+		return
+	}
+
+	status := func(url string) int {
+		resp, err := http.Get(url)
+		if !assert.NoError(err, url) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	assert.Equal(http.StatusOK, status("http://"+addr+"/api/status"))
+	assert.Equal(http.StatusOK, status("http://"+addr+"/api/ready"))
+	// operational endpoints are scraped without credentials, user routes still need them
+	assert.Equal(http.StatusOK, status("http://"+addr+"/api/metrics/concurrency"))
+	assert.Equal(http.StatusUnauthorized, status("http://"+addr+"/api/sessions"))
+	assert.Equal(http.StatusUnauthorized, status("http://"+addr+"/debug/pprof/"))
+	assert.Equal(http.StatusUnauthorized, status("http://"+addr+"/debug/pprof/heap"))
+}
+
+func TestListenErrorMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	bindErr := &net.OpError{Op: "listen", Net: "tcp", Err: os.NewSyscallError("bind", syscall.EACCES)}
+	assert.Contains(listenErrorMessage(":80", bindErr), "privileged port 80")
+	assert.Contains(listenErrorMessage("127.0.0.1:443", bindErr), "CAP_NET_BIND_SERVICE")
+	assert.Equal("Could not listen.", listenErrorMessage(":2137", bindErr))
+
+	inUseErr := &net.OpError{Op: "listen", Net: "tcp", Err: os.NewSyscallError("bind", syscall.EADDRINUSE)}
+	assert.Equal("Could not listen.", listenErrorMessage(":80", inUseErr))
+	assert.Equal("Could not listen.", listenErrorMessage(":80", errors.New("unexpected")))
+}
+
+func TestListenAndServeShutdownInMaintenance(t *testing.T) {
+	assert := assert.New(t)
+	t.Setenv("MAINTENANCE_MODE", "true")
+
+	bdb, err := buntdb.Open(":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer bdb.Close()
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN("postgres://test@127.0.0.1:1/test"))), pgdialect.New())
+	defer db.Close()
+
+	addr := freeAddr(t)
+	shutdown := listenAndServe(context.Background(), serverConfig{
+		bdb:           bdb,
+		db:            db,
+		debug:         true,
+		addr:          addr,
+		adminShutdown: func() {},
+	})
+	defer func() {
+		assert.NoError(shutdown())
+	}()
+	if !assert.True(awaitListening(addr)) {
+		return
+	}
+
+	status := func(method string, url string) int {
+		req, err := http.NewRequest(method, url, nil)
+		if !assert.NoError(err, url) {
+			return 0
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if !assert.NoError(err, url) {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	// reaching authorization means maintenance handler didn't reject it first
+	assert.Equal(http.StatusUnauthorized, status("POST", "http://"+addr+"/api/admin/shutdown"))
+	assert.Equal(http.StatusUnauthorized, status("PUT", "http://"+addr+"/api/admin/maintenance"))
+	assert.Equal(http.StatusServiceUnavailable, status("DELETE", "http://"+addr+"/api/sessions/other"))
+}
+
+func TestListenAndServeDeprecatedRoutes(t *testing.T) {
+	assert := assert.New(t)
+
+	bdb, err := buntdb.Open(":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer bdb.Close()
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN("postgres://test@127.0.0.1:1/test"))), pgdialect.New())
+	defer db.Close()
+
+	deprecatedRoutes, err := rest.ParseDeprecatedRoutes("/api/about|2022-06-01|use /api/v1/about")
+	if !assert.NoError(err) {
+		return
+	}
+	addr := freeAddr(t)
+	shutdown := listenAndServe(context.Background(), serverConfig{
+		bdb:              bdb,
+		db:               db,
+		debug:            true,
+		addr:             addr,
+		deprecatedRoutes: deprecatedRoutes,
+	})
+	defer func() {
+		assert.NoError(shutdown())
+	}()
+	if !assert.True(awaitListening(addr)) {
+		return
+	}
+
+	resp, err := http.Get("http://" + addr + "/api/about")
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Equal(http.StatusOK, resp.StatusCode)
+		assert.Equal("true", resp.Header.Get("Deprecation"))
+		assert.Equal("Wed, 01 Jun 2022 00:00:00 GMT", resp.Header.Get("Sunset"))
+		assert.Equal(`299 - "Deprecated API: use /api/v1/about"`, resp.Header.Get("Warning"))
+	}
+	resp, err = http.Get("http://" + addr + "/api/status")
+	if assert.NoError(err) {
+		resp.Body.Close()
+		assert.Empty(resp.Header.Get("Deprecation"))
+	}
+}
+
+func TestListenAndServeOperationalRequestLogging(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	bdb, err := buntdb.Open(":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer bdb.Close()
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN("postgres://test@127.0.0.1:1/test"))), pgdialect.New())
+	defer db.Close()
+
+	for _, logOperational := range []bool{false, true} {
+		if logOperational {
+			t.Setenv("LOG_OPERATIONAL_REQUESTS", "true")
+		}
+		addr := freeAddr(t)
+		shutdown := listenAndServe(context.Background(), serverConfig{bdb: bdb, db: db, debug: true, addr: addr})
+		if !assert.True(awaitListening(addr)) {
+			assert.NoError(shutdown())
+			return
+		}
+
+		logHook.Reset()
+		resp, err := http.Get("http://" + addr + "/api/status")
+		if assert.NoError(err) {
+			resp.Body.Close()
+		}
+		logged := false
+		for _, entry := range logHook.AllEntries() {
+			if entry.Message == "Handling request." && entry.Data["path"] == "/api/status" {
+				logged = true
+			}
+		}
+		assert.Equal(logOperational, logged)
+		assert.NoError(shutdown())
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	_ "github.com/uptrace/bun/driver/pgdriver"
-	"github.com/uptrace/bun/extra/bundebug"
 )
 
 // inspiration: https://stackoverflow.com/a/64222654 (by brpaz)
@@ -116,7 +115,7 @@ func createTestPgDb() (func(), error) {
 			return fmt.Errorf("sqldb ping: %w", sqldb.Ping())
 		}
 		bdb := bun.NewDB(sqldb, pgdialect.New())
-		bdb.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
+		bdb.AddQueryHook(&persistent.QueryHook{})
 		createDbSchema(context.Background(), bdb)
 		_ = bdb.Close()
 		_ = sqldb.Close()
@@ -132,13 +131,7 @@ func createTestPgDb() (func(), error) {
 }
 
 func createDbSchema(ctx context.Context, db *bun.DB) {
-	models := []interface{}{
-		(*persistent.User)(nil),
-		(*persistent.ActivityLog)(nil),
-		(*persistent.Profile)(nil),
-		(*persistent.Program)(nil),
-	}
-	for _, model := range models {
+	for _, model := range persistent.Models {
 		modelType := reflect.TypeOf(model)
 		logrus.WithField("model", modelType).Debugln("Creating table.")
 		_, err := db.NewCreateTable().IfNotExists().Model(model).Exec(ctx)
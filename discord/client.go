@@ -0,0 +1,170 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultRequestTimeout = 10 * time.Second
+	defaultMaxAttempts    = 3
+	defaultBaseDelay      = 250 * time.Millisecond
+	// Retry-After above this is not worth holding user request for, fail instead.
+	maxRetryAfter = 10 * time.Second
+)
+
+// Shared client for every outbound discord api call.
+// Retries transient failures (network errors, 429 and 5xx gateway errors) with backoff,
+// honouring Retry-After sent by the target.
+type Client struct {
+	HTTP        *http.Client
+	MaxAttempts int
+	BaseDelay   time.Duration
+
+	succeeded uint64
+	failed    uint64
+	retried   uint64
+}
+
+type ClientStats struct {
+	Succeeded uint64 `json:"succeeded"`
+	Failed    uint64 `json:"failed"`
+	Retried   uint64 `json:"retried"`
+}
+
+func NewClient() *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 16
+	return &Client{
+		HTTP: &http.Client{
+			Transport: transport,
+			Timeout:   defaultRequestTimeout,
+		},
+		MaxAttempts: defaultMaxAttempts,
+		BaseDelay:   defaultBaseDelay,
+	}
+}
+
+// Sends request and reads whole response body.
+// Only idempotent requests are retried, POST may have taken effect before response got lost.
+// Request body must be replayable (see http.Request.GetBody) to be retried,
+// which is the case for bodies passed to http.NewRequest as bytes or strings reader.
+// Backoff is cut short when request context is done.
+func (c *Client) Do(req *http.Request) (int, []byte, error) {
+	maxAttempts := c.MaxAttempts
+	if !isIdempotent(req.Method) || (req.Body != nil && req.GetBody == nil) {
+		maxAttempts = 1
+	}
+	for attempt := 1; ; attempt++ {
+		statusCode, body, retryAfter, err := c.do(req)
+		retryable := err != nil || isRetryableStatus(statusCode)
+		if !retryable || attempt >= maxAttempts {
+			if err != nil || statusCode >= 500 || isRetryableStatus(statusCode) {
+				atomic.AddUint64(&c.failed, 1)
+			} else {
+				atomic.AddUint64(&c.succeeded, 1)
+			}
+			return statusCode, body, err
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > maxRetryAfter {
+				atomic.AddUint64(&c.failed, 1)
+				return statusCode, body, err
+			}
+			delay = retryAfter
+		}
+		atomic.AddUint64(&c.retried, 1)
+		if err := sleepContext(req.Context(), delay); err != nil {
+			atomic.AddUint64(&c.failed, 1)
+			return 0, nil, err
+		}
+
+		if req.GetBody != nil {
+			replay, err := req.GetBody()
+			if err != nil {
+				atomic.AddUint64(&c.failed, 1)
+				return 0, nil, fmt.Errorf("replay body: %w", err)
+			}
+			req.Body = replay
+		}
+	}
+}
+
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		Succeeded: atomic.LoadUint64(&c.succeeded),
+		Failed:    atomic.LoadUint64(&c.failed),
+		Retried:   atomic.LoadUint64(&c.retried),
+	}
+}
+
+func (c *Client) do(req *http.Request) (statusCode int, body []byte, retryAfter time.Duration, err error) {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("http do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, 0, fmt.Errorf("read body: %w", err)
+	}
+	return resp.StatusCode, body, parseRetryAfter(resp.Header.Get("Retry-After")), nil
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.BaseDelay << (attempt - 1)
+	if delay <= 0 {
+		return 0
+	}
+	// jitter keeps concurrent retries from hitting discord in lockstep
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Only delay-seconds form is supported, discord never sends http-date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
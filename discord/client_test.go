@@ -0,0 +1,143 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientRetriesUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0.01")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.BaseDelay = time.Hour // Retry-After must take precedence
+	req, err := http.NewRequest(http.MethodPut, server.URL, bytes.NewReader([]byte("im working")))
+	if !assert.NoError(err) {
+		return
+	}
+	statusCode, body, err := client.Do(req)
+	if assert.NoError(err) {
+		assert.Equal(http.StatusOK, statusCode)
+		assert.Equal("im working", string(body))
+	}
+	assert.Equal(int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(ClientStats{Succeeded: 1, Retried: 1}, client.Stats())
+}
+
+func TestClientGivesUp(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.BaseDelay = time.Millisecond
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if !assert.NoError(err) {
+		return
+	}
+	statusCode, _, err := client.Do(req)
+	assert.NoError(err)
+	assert.Equal(http.StatusBadGateway, statusCode)
+	assert.Equal(int32(client.MaxAttempts), atomic.LoadInt32(&calls))
+	assert.Equal(ClientStats{Failed: 1, Retried: uint64(client.MaxAttempts - 1)}, client.Stats())
+}
+
+func TestClientDoesNotRetryClientErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if !assert.NoError(err) {
+		return
+	}
+	statusCode, _, err := client.Do(req)
+	assert.NoError(err)
+	assert.Equal(http.StatusUnauthorized, statusCode)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClientDoesNotRetryPost(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.BaseDelay = time.Millisecond
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("code=2137"))
+	if !assert.NoError(err) {
+		return
+	}
+	statusCode, _, err := client.Do(req)
+	assert.NoError(err)
+	assert.Equal(http.StatusServiceUnavailable, statusCode)
+	assert.Equal(int32(1), atomic.LoadInt32(&calls), "oauth code may be already consumed, post must not be repeated")
+}
+
+func TestClientBackoffCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	client.BaseDelay = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if !assert.NoError(err) {
+		return
+	}
+	started := time.Now()
+	_, _, err = client.Do(req)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Less(int64(time.Since(started)), int64(time.Second))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(time.Duration(0), parseRetryAfter(""))
+	assert.Equal(2*time.Second, parseRetryAfter("2"))
+	assert.Equal(1500*time.Millisecond, parseRetryAfter("1.5"))
+	assert.Equal(time.Duration(0), parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT"))
+	assert.Equal(time.Duration(0), parseRetryAfter("-1"))
+}
@@ -1,8 +1,11 @@
 package discord
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,25 +18,15 @@ const (
 	GuildAddStatusAlreadyMember GuildAddStatus = 204
 )
 
-type GuildMemberAdd = func(userAccessToken string, userId string) (GuildAddStatus, error)
+type GuildMemberAdd = func(ctx context.Context, userAccessToken string, userId string) (GuildAddStatus, error)
 
-func MockGuildMemberAdd(userAccessToken string, userId string) (GuildAddStatus, error) {
+func MockGuildMemberAdd(ctx context.Context, userAccessToken string, userId string) (GuildAddStatus, error) {
 	return GuildAddStatusSuccess, nil
 }
 
 // Impl of discord rest api /guilds/{guild.id}/members/{user.id}
-func RestGuildMemberAdd(botToken string, guildId string) GuildMemberAdd {
-	return func(userAccessToken string, userId string) (GuildAddStatus, error) {
-		agent := fiber.AcquireAgent()
-		defer fiber.ReleaseAgent(agent)
-
-		req := agent.Request()
-		req.Header.SetMethod(fiber.MethodPut)
-		req.Header.Set(fiber.HeaderAuthorization, "Bot "+botToken)
-		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
-		req.SetRequestURI(fmt.Sprintf("https://discord.com/api/guilds/%s/members/%s",
-			url.PathEscape(guildId), url.PathEscape(userId)))
-
+func RestGuildMemberAdd(client *Client, botToken string, guildId string) GuildMemberAdd {
+	return func(ctx context.Context, userAccessToken string, userId string) (GuildAddStatus, error) {
 		type ReqBody struct {
 			AccessToken string `json:"access_token"`
 		}
@@ -41,16 +34,18 @@ func RestGuildMemberAdd(botToken string, guildId string) GuildMemberAdd {
 		if err != nil {
 			return 0, fmt.Errorf("marshal body: %w", err)
 		}
-		req.SetBody(reqBody)
 
-		err = agent.Parse()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("https://discord.com/api/guilds/%s/members/%s",
+			url.PathEscape(guildId), url.PathEscape(userId)), bytes.NewReader(reqBody))
 		if err != nil {
-			return 0, fmt.Errorf("agent parse: %w", err)
+			return 0, fmt.Errorf("new request: %w", err)
 		}
+		req.Header.Set(fiber.HeaderAuthorization, "Bot "+botToken)
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
 
-		statusCode, body, errs := agent.Bytes()
-		if errs != nil {
-			return 0, fmt.Errorf("agent bytes: %v", errs)
+		statusCode, body, err := client.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("client do: %w", err)
 		}
 		if statusCode != fiber.StatusCreated && statusCode != fiber.StatusNoContent {
 			if statusCode == fiber.StatusUnauthorized {
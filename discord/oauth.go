@@ -1,10 +1,13 @@
 package discord
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
@@ -14,7 +17,7 @@ var ErrOAuthInvalidCode = errors.New("discord: oauth invalid code")
 
 type OAuthUrlFactory = func() string
 
-type AccessTokenExchanger = func(code string) (AccessTokenResponse, error)
+type AccessTokenExchanger = func(ctx context.Context, code string) (AccessTokenResponse, error)
 
 type AccessTokenResponse struct {
 	AccessToken  string `json:"access_token"`
@@ -44,32 +47,27 @@ func RestOAuthUrlFactory(clientId string, redirectUri string) OAuthUrlFactory {
 	}
 }
 
-func RestAccessTokenExchanger(clientId string, clientSecret string, redirectUri string) AccessTokenExchanger {
-	return func(code string) (AccessTokenResponse, error) {
-		agent := fiber.AcquireAgent()
-		defer fiber.ReleaseAgent(agent)
-
-		req := agent.Request()
-		req.Header.SetMethod(fiber.MethodPost)
-		req.SetRequestURI("https://discord.com/api/oauth2/token")
-
-		args := fiber.AcquireArgs()
-		defer fiber.ReleaseArgs(args)
-
-		args.Add("grant_type", "authorization_code")
-		args.Add("client_id", clientId)
-		args.Add("client_secret", clientSecret)
-		args.Add("code", code)
-		args.Add("redirect_uri", redirectUri)
-
-		err := agent.Form(args).Parse()
+func RestAccessTokenExchanger(client *Client, clientId string, clientSecret string, redirectUri string) AccessTokenExchanger {
+	return func(ctx context.Context, code string) (AccessTokenResponse, error) {
+		form := url.Values{}
+		form.Set("grant_type", "authorization_code")
+		form.Set("client_id", clientId)
+		form.Set("client_secret", clientSecret)
+		form.Set("code", code)
+		form.Set("redirect_uri", redirectUri)
+
+		// POST is never retried by client, code is single use and may be already consumed
+		// when response gets lost
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://discord.com/api/oauth2/token",
+			strings.NewReader(form.Encode()))
 		if err != nil {
-			return AccessTokenResponse{}, fmt.Errorf("agent parse: %w", err)
+			return AccessTokenResponse{}, fmt.Errorf("new request: %w", err)
 		}
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
 
-		statusCode, bodyBytes, errArr := agent.Bytes()
-		if len(errArr) != 0 {
-			return AccessTokenResponse{}, fmt.Errorf("agent bytes: %v", errArr)
+		statusCode, bodyBytes, err := client.Do(req)
+		if err != nil {
+			return AccessTokenResponse{}, fmt.Errorf("client do: %w", err)
 		}
 		if statusCode != fiber.StatusOK {
 			return accessTokenExchangeError(statusCode, bodyBytes)
@@ -1,8 +1,10 @@
 package discord
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -18,46 +20,43 @@ func (u User) AvatarUrl() string {
 	return fmt.Sprintf("https://cdn.discordapp.com/avatars/%s/%s.png", u.Id, u.AvatarHash)
 }
 
-type UserMe = func(token Token) (User, error)
+type UserMe = func(ctx context.Context, token Token) (User, error)
 
 type UserMeProvider = func() UserMe
 
 // Impl of discord rest api /user/@me
-func RestUserMe(token Token) (User, error) {
-	agent := fiber.AcquireAgent()
-	defer fiber.ReleaseAgent(agent)
-
-	req := agent.Request()
-	req.Header.SetMethod(fiber.MethodGet)
-	req.SetRequestURI("https://discord.com/api/users/@me")
-
-	req.Header.Set(fiber.HeaderAuthorization, token.String())
-
-	err := agent.Parse()
-	if err != nil {
-		return User{}, fmt.Errorf("agent parse: %w", err)
-	}
+func RestUserMe(client *Client) UserMe {
+	return func(ctx context.Context, token Token) (User, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://discord.com/api/users/@me", nil)
+		if err != nil {
+			return User{}, fmt.Errorf("new request: %w", err)
+		}
+		req.Header.Set(fiber.HeaderAuthorization, token.String())
 
-	statusCode, body, errs := agent.Bytes()
-	if errs != nil {
-		return User{}, fmt.Errorf("agent bytes: %v", errs)
-	}
+		statusCode, body, err := client.Do(req)
+		if err != nil {
+			return User{}, fmt.Errorf("client do: %w", err)
+		}
 
-	if statusCode != fiber.StatusOK {
-		if statusCode == fiber.StatusUnauthorized {
-			return User{}, ErrUnauthorized
-		} else {
-			return User{}, fmt.Errorf("invalid status code %d: %s", statusCode, string(body))
+		if statusCode != fiber.StatusOK {
+			if statusCode == fiber.StatusUnauthorized {
+				return User{}, ErrUnauthorized
+			} else {
+				return User{}, fmt.Errorf("invalid status code %d: %s", statusCode, string(body))
+			}
 		}
-	}
 
-	var response User
-	if err = json.Unmarshal(body, &response); err != nil {
-		return User{}, fmt.Errorf("unmarshal body: %w", err)
+		var response User
+		if err = json.Unmarshal(body, &response); err != nil {
+			return User{}, fmt.Errorf("unmarshal body: %w", err)
+		}
+		return response, nil
 	}
-	return response, nil
 }
 
-func RestUserMeProvider() UserMe {
-	return RestUserMe
+func RestUserMeProvider(client *Client) UserMeProvider {
+	userMe := RestUserMe(client)
+	return func() UserMe {
+		return userMe
+	}
 }
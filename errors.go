@@ -0,0 +1,31 @@
+package buzza
+
+import "errors"
+
+// Error categories returned by stores and services, match them with errors.Is.
+// Transport maps every category to its own status, so most handlers can return errors as is.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrValidation  = errors.New("validation failed")
+	ErrConflict    = errors.New("conflict")
+	ErrUnavailable = errors.New("unavailable")
+	ErrTimeout     = errors.New("timeout")
+)
+
+// Domain error of given category. Message is safe to show to clients.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func NewError(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
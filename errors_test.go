@@ -0,0 +1,24 @@
+package buzza
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKind(t *testing.T) {
+	assert := assert.New(t)
+
+	err := fmt.Errorf("repo promote: %w", ErrProgramNotFound)
+	assert.ErrorIs(err, ErrProgramNotFound)
+	assert.ErrorIs(err, ErrNotFound)
+	assert.False(errors.Is(err, ErrValidation))
+
+	var domainErr *Error
+	if assert.True(errors.As(err, &domainErr)) {
+		assert.Equal("program not found", domainErr.Message)
+	}
+	assert.ErrorIs(ErrInvalidPromotion, ErrValidation)
+}
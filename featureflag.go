@@ -0,0 +1,59 @@
+package buzza
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+)
+
+// Server controlled toggle queried by client.
+type FeatureFlag struct {
+	Key     string
+	Enabled bool
+	// Optional flag payload, raw json.
+	Value json.RawMessage
+	// Scope of the flag. Empty OS or Branch matches every os or branch.
+	OS     string
+	Branch string
+}
+
+// The more scope fields are set, the more specific the flag is.
+func (f FeatureFlag) specificity() int {
+	specificity := 0
+	if f.OS != "" {
+		specificity += 2
+	}
+	if f.Branch != "" {
+		specificity++
+	}
+	return specificity
+}
+
+// Picks the most specific flag for every key, so scoped flags override global ones.
+// Flags should already match platform they are resolved for. Result is sorted by key.
+func ResolveFeatureFlags(flags []FeatureFlag) []FeatureFlag {
+	byKey := make(map[string]FeatureFlag, len(flags))
+	for _, flag := range flags {
+		current, ok := byKey[flag.Key]
+		if !ok || flag.specificity() > current.specificity() {
+			byKey[flag.Key] = flag
+		}
+	}
+
+	resolved := make([]FeatureFlag, 0, len(byKey))
+	for _, flag := range byKey {
+		resolved = append(resolved, flag)
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].Key < resolved[j].Key
+	})
+	return resolved
+}
+
+type FeatureFlagStore interface {
+	// Get flags applying to specified os and branch, resolved with ResolveFeatureFlags.
+	Applicable(ctx context.Context, os string, branch string) ([]FeatureFlag, error)
+
+	// Create flag or replace flag with the same key and scope.
+	Put(ctx context.Context, flag FeatureFlag) error
+}
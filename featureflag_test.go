@@ -0,0 +1,28 @@
+package buzza
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveFeatureFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	resolved := ResolveFeatureFlags([]FeatureFlag{
+		{Key: "new_ui", Enabled: false},
+		{Key: "new_ui", Enabled: true, OS: "Windows", Branch: "beta"},
+		{Key: "new_ui", Enabled: false, OS: "Windows"},
+		{Key: "cps_limit", Enabled: true, Value: []byte(`20`), Branch: "beta"},
+		{Key: "cps_limit", Enabled: true, Value: []byte(`15`)},
+		{Key: "cps_limit", Enabled: true, Value: []byte(`30`), OS: "Windows"},
+		{Key: "macros", Enabled: true},
+	})
+	assert.Equal([]FeatureFlag{
+		{Key: "cps_limit", Enabled: true, Value: []byte(`30`), OS: "Windows"},
+		{Key: "macros", Enabled: true},
+		{Key: "new_ui", Enabled: true, OS: "Windows", Branch: "beta"},
+	}, resolved)
+
+	assert.Empty(ResolveFeatureFlags(nil))
+}
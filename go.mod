@@ -4,11 +4,13 @@ go 1.17
 
 require (
 	github.com/gofiber/fiber/v2 v2.26.0
+	github.com/klauspost/cpuid/v2 v2.0.11
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.7.0
 	github.com/uptrace/bun v1.0.22
 	github.com/uptrace/bun/dialect/pgdialect v1.0.22
 	github.com/uptrace/bun/driver/pgdriver v1.0.22
+	golang.org/x/sync v0.0.0-20201207232520-09787c993a3a
 )
 
 require (
@@ -28,7 +30,6 @@ require (
 	github.com/ory/dockertest v3.3.5+incompatible
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/tmthrgd/go-hex v0.0.0-20190904060850-447a3041c3bc // indirect
-	github.com/uptrace/bun/extra/bundebug v1.0.22
 	github.com/vmihailenco/msgpack/v5 v5.3.5 // indirect
 	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/crypto v0.0.0-20220213190939-1e6e3497d506 // indirect
@@ -51,7 +52,6 @@ require (
 	github.com/golang/protobuf v1.5.0 // indirect
 	github.com/gotestyourself/gotestyourself v2.2.0+incompatible // indirect
 	github.com/klauspost/compress v1.14.2 // indirect
-	github.com/klauspost/cpuid/v2 v2.0.11 // indirect
 	github.com/lib/pq v1.10.4 // indirect
 	github.com/moby/sys/mountinfo v0.5.0 // indirect
 	github.com/mrunalp/fileutils v0.5.0 // indirect
@@ -3,13 +3,15 @@ package inmem
 import (
 	"context"
 	"sync"
-	"time"
 
 	"github.com/buzkaaclicker/buzza"
 	"github.com/buzkaaclicker/buzza/discord"
 )
 
 type UserStore struct {
+	// buzza.RealClock unless replaced in tests.
+	Clock buzza.Clock
+
 	lastId int64
 	users  map[buzza.UserId]buzza.User
 	mutex  sync.RWMutex
@@ -17,6 +19,7 @@ type UserStore struct {
 
 func NewUserStore() UserStore {
 	return UserStore{
+		Clock:  buzza.RealClock{},
 		lastId: 0,
 		users:  map[buzza.UserId]buzza.User{},
 		mutex:  sync.RWMutex{},
@@ -31,7 +34,7 @@ func (s *UserStore) RegisterDiscordUser(ctx context.Context, u discord.User, ref
 	uid := buzza.UserId(s.lastId)
 	user := buzza.User{
 		Id:        uid,
-		CreatedAt: time.Now(),
+		CreatedAt: s.Clock.Now(),
 		Roles:     []buzza.Role{},
 		Discord: buzza.UserDiscord{
 			Id:           u.Id,
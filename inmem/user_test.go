@@ -3,9 +3,11 @@ package inmem
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/buzkaaclicker/buzza"
 	"github.com/buzkaaclicker/buzza/discord"
+	"github.com/buzkaaclicker/buzza/mock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,7 +15,9 @@ func TestUserStore(t *testing.T) {
 	ctx := context.Background()
 	assert := assert.New(t)
 
+	createdAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
 	s := NewUserStore()
+	s.Clock = mock.NewClock(createdAt)
 	_, err := s.ById(ctx, 1)
 	assert.Equal(buzza.ErrUserNotFound, err)
 
@@ -27,6 +31,7 @@ func TestUserStore(t *testing.T) {
 	if !assert.NoError(err) {
 		return
 	}
+	assert.Equal(createdAt, u.CreatedAt)
 
 	ufound, err := s.ById(ctx, u.Id)
 	if !assert.NoError(err) {
@@ -0,0 +1,22 @@
+package buzza
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type logContextKey struct{}
+
+// Attaches log entry to context, so code called with it logs with the same fields e.g. request id.
+func ContextWithLog(ctx context.Context, log *logrus.Entry) context.Context {
+	return context.WithValue(ctx, logContextKey{}, log)
+}
+
+// Log entry attached with ContextWithLog or plain standard logger entry if there is none.
+func LogFromContext(ctx context.Context) *logrus.Entry {
+	if log, ok := ctx.Value(logContextKey{}).(*logrus.Entry); ok {
+		return log
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
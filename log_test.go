@@ -0,0 +1,27 @@
+package buzza
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogFromContext(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	LogFromContext(context.Background()).Infoln("Without fields.")
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Empty(entry.Data)
+	}
+
+	ctx := ContextWithLog(context.Background(), logrus.WithField("request_id", "2137"))
+	LogFromContext(ctx).WithField("table", "user").Infoln("With fields.")
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal(logrus.Fields{"request_id": "2137", "table": "user"}, entry.Data)
+	}
+}
@@ -0,0 +1,32 @@
+package mock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+)
+
+// Clock standing still until moved with Advance.
+type Clock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+var _ buzza.Clock = (*Clock)(nil)
+
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *Clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
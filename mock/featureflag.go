@@ -0,0 +1,21 @@
+package mock
+
+import (
+	"context"
+
+	"github.com/buzkaaclicker/buzza"
+)
+
+type FeatureFlagStore struct {
+	ApplicableFn func(ctx context.Context, os string, branch string) ([]buzza.FeatureFlag, error)
+
+	PutFn func(ctx context.Context, flag buzza.FeatureFlag) error
+}
+
+func (s FeatureFlagStore) Applicable(ctx context.Context, os string, branch string) ([]buzza.FeatureFlag, error) {
+	return s.ApplicableFn(ctx, os, branch)
+}
+
+func (s FeatureFlagStore) Put(ctx context.Context, flag buzza.FeatureFlag) error {
+	return s.PutFn(ctx, flag)
+}
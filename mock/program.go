@@ -9,9 +9,23 @@ import (
 type ProgramStore struct {
 	LatestProgramFilesFn func(ctx context.Context,
 		fileType string, os string, arch string, branch string) ([]buzza.ProgramFile, error)
+
+	PlatformsFn func(ctx context.Context, fileType string) ([]buzza.ProgramPlatform, error)
+
+	PromoteFn func(ctx context.Context, programId int, branch string, mode buzza.PromotionMode,
+		promotedBy buzza.UserId) (buzza.Program, error)
 }
 
 func (s ProgramStore) LatestProgramFiles(ctx context.Context,
 	fileType string, os string, arch string, branch string) ([]buzza.ProgramFile, error) {
 	return s.LatestProgramFilesFn(ctx, fileType, os, arch, branch)
 }
+
+func (s ProgramStore) Platforms(ctx context.Context, fileType string) ([]buzza.ProgramPlatform, error) {
+	return s.PlatformsFn(ctx, fileType)
+}
+
+func (s ProgramStore) Promote(ctx context.Context, programId int, branch string,
+	mode buzza.PromotionMode, promotedBy buzza.UserId) (buzza.Program, error) {
+	return s.PromoteFn(ctx, programId, branch, mode, promotedBy)
+}
@@ -1,12 +1,5 @@
 package mock
 
-import (
-	"sync"
-
-	"github.com/buzkaaclicker/buzza"
-)
-
-
 // type SessionStore interface {
 // 	RegisterNew(ctx context.Context, userId UserId, ip string, userAgent string) (Session, error)
 
@@ -0,0 +1,82 @@
+package persistent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/uptrace/bun"
+)
+
+type FeatureFlag struct {
+	bun.BaseModel `bun:"table:feature_flag"`
+
+	Id        int64           `bun:",pk,autoincrement"`
+	CreatedAt time.Time       `bun:",nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time       `bun:",nullzero,notnull,default:current_timestamp"`
+	Key       string          `bun:",notnull,unique:flag_scope,type:varchar(100)"`
+	OS        string          `bun:",notnull,unique:flag_scope,type:varchar(30)"`
+	Branch    string          `bun:",notnull,unique:flag_scope,type:varchar(255)"`
+	Enabled   bool            `bun:",notnull"`
+	Value     json.RawMessage `bun:",nullzero,type:jsonb"`
+}
+
+func (f FeatureFlag) ToDomain() buzza.FeatureFlag {
+	value := f.Value
+	if string(value) == "null" {
+		// nil value is stored as sql NULL, json null is left by rows written before that
+		value = nil
+	}
+	return buzza.FeatureFlag{
+		Key:     f.Key,
+		Enabled: f.Enabled,
+		Value:   value,
+		OS:      f.OS,
+		Branch:  f.Branch,
+	}
+}
+
+type FeatureFlagStore struct {
+	DB *bun.DB
+}
+
+var _ buzza.FeatureFlagStore = (*FeatureFlagStore)(nil)
+
+func (s *FeatureFlagStore) Applicable(ctx context.Context, os string, branch string) ([]buzza.FeatureFlag, error) {
+	var flags []FeatureFlag
+	err := s.DB.NewSelect().
+		Model(&flags).
+		Where("os='' OR os=?", os).
+		Where("branch='' OR branch=?", branch).
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	df := make([]buzza.FeatureFlag, len(flags))
+	for i, f := range flags {
+		df[i] = f.ToDomain()
+	}
+	return buzza.ResolveFeatureFlags(df), nil
+}
+
+func (s *FeatureFlagStore) Put(ctx context.Context, flag buzza.FeatureFlag) error {
+	model := &FeatureFlag{
+		Key:     flag.Key,
+		OS:      flag.OS,
+		Branch:  flag.Branch,
+		Enabled: flag.Enabled,
+		Value:   flag.Value,
+	}
+	_, err := s.DB.NewInsert().
+		Model(model).
+		On(`CONFLICT (key, os, branch) DO UPDATE SET enabled=EXCLUDED.enabled, ` +
+			`value=EXCLUDED.value, updated_at=current_timestamp`).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("upsert query: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package persistent
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlagStore(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	db := PgOpenTest(ctx)
+	defer db.Close()
+
+	store := &FeatureFlagStore{DB: db}
+	for _, flag := range []buzza.FeatureFlag{
+		{Key: "new_ui", Enabled: false},
+		{Key: "new_ui", Enabled: true, OS: "Windows", Branch: "beta"},
+		{Key: "cps_limit", Enabled: true, Value: []byte(`15`)},
+		{Key: "cps_limit", Enabled: true, Value: []byte(`20`), Branch: "beta"},
+		{Key: "macros", Enabled: true, OS: "macOS"},
+	} {
+		if !assert.NoError(store.Put(ctx, flag)) {
+			return
+		}
+	}
+
+	flags, err := store.Applicable(ctx, "Windows", "beta")
+	if assert.NoError(err) {
+		assert.Equal([]buzza.FeatureFlag{
+			{Key: "cps_limit", Enabled: true, Value: []byte(`20`), Branch: "beta"},
+			{Key: "new_ui", Enabled: true, OS: "Windows", Branch: "beta"},
+		}, flags)
+	}
+
+	// put with the same key and scope replaces flag
+	assert.NoError(store.Put(ctx, buzza.FeatureFlag{Key: "new_ui", Enabled: true}))
+	flags, err = store.Applicable(ctx, "macOS", "stable")
+	if assert.NoError(err) {
+		assert.Equal([]buzza.FeatureFlag{
+			{Key: "cps_limit", Enabled: true, Value: []byte(`15`)},
+			{Key: "macros", Enabled: true, OS: "macOS"},
+			{Key: "new_ui", Enabled: true},
+		}, flags)
+	}
+}
+
+func TestFeatureFlagToDomainNullValue(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(FeatureFlag{Key: "new_ui", Value: []byte(`null`)}.ToDomain().Value)
+	assert.Nil(FeatureFlag{Key: "new_ui"}.ToDomain().Value)
+	assert.Equal(json.RawMessage(`{"max":20}`), FeatureFlag{Key: "cps_limit", Value: []byte(`{"max":20}`)}.ToDomain().Value)
+}
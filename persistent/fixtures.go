@@ -0,0 +1,56 @@
+package persistent
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/uptrace/bun"
+)
+
+var ErrDatabaseNotEmpty = buzza.NewError(buzza.ErrConflict, "database not empty")
+
+//go:embed fixtures/programs.json
+var fixtures embed.FS
+
+func fixturePrograms() ([]Program, error) {
+	data, err := fixtures.ReadFile("fixtures/programs.json")
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+	var programs []Program
+	if err := json.Unmarshal(data, &programs); err != nil {
+		return nil, fmt.Errorf("unmarshal: %w", err)
+	}
+	return programs, nil
+}
+
+// Loads sample programs for local testing and demos. Refuses with ErrDatabaseNotEmpty when
+// any program is already published, so running it again never touches existing data.
+func SeedFixtures(ctx context.Context, db *bun.DB) error {
+	programs, err := fixturePrograms()
+	if err != nil {
+		return fmt.Errorf("fixture programs: %w", err)
+	}
+
+	return withRetryTx(ctx, db, func(ctx context.Context, tx bun.Tx) error {
+		// lock table, so concurrently starting instances can't both seed
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE program IN EXCLUSIVE MODE"); err != nil {
+			return fmt.Errorf("lock: %w", err)
+		}
+		count, err := tx.NewSelect().Model((*Program)(nil)).WhereAllWithDeleted().Count(ctx)
+		if err != nil {
+			return fmt.Errorf("count programs: %w", err)
+		}
+		if count > 0 {
+			return ErrDatabaseNotEmpty
+		}
+
+		if _, err := tx.NewInsert().Model(&programs).Exec(ctx); err != nil {
+			return fmt.Errorf("insert programs: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,93 @@
+package persistent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixturePrograms(t *testing.T) {
+	assert := assert.New(t)
+
+	programs, err := fixturePrograms()
+	if !assert.NoError(err) {
+		return
+	}
+	assert.NotEmpty(programs)
+	for _, p := range programs {
+		assert.NotEmpty(p.Type)
+		assert.NotEmpty(p.OS)
+		assert.NotEmpty(p.Arch)
+		assert.NotEmpty(p.Branch)
+		if assert.NotEmpty(p.Files) {
+			assert.NotEmpty(p.Files[0].DownloadUrl)
+		}
+	}
+}
+
+func TestSeedFixtures(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	db := PgOpenTest(ctx)
+	defer db.Close()
+	// other tests share public schema, seed separate empty one through single connection
+	db.SetMaxOpenConns(1)
+	for _, query := range []string{
+		"DROP SCHEMA IF EXISTS seed_test CASCADE",
+		"CREATE SCHEMA seed_test",
+		"SET search_path TO seed_test",
+	} {
+		if _, err := db.ExecContext(ctx, query); !assert.NoError(err) {
+			return
+		}
+	}
+	defer db.ExecContext(ctx, "DROP SCHEMA seed_test CASCADE")
+	if _, err := db.NewCreateTable().Model((*Program)(nil)).Exec(ctx); !assert.NoError(err) {
+		return
+	}
+
+	if !assert.NoError(SeedFixtures(ctx, db)) {
+		return
+	}
+	store := ProgramStore{DB: db}
+	files, err := store.LatestProgramFiles(ctx, "installer", "Windows", "x86-64", "stable")
+	if assert.NoError(err) {
+		assert.Equal([]buzza.ProgramFile{{
+			Path:        "BuzkaaClickerSetup.exe",
+			DownloadUrl: "https://buzkaaclicker.pl/demo/BuzkaaClickerSetup.exe",
+			Hash:        "a3f1c2d4e5b6a7980112233445566778899aabbccddeeff00112233445566778",
+		}}, files)
+	}
+
+	// programs may consist of several files
+	files, err = store.LatestProgramFiles(ctx, "clicker", "Windows", "x86-64", "stable")
+	if assert.NoError(err) {
+		assert.Equal([]buzza.ProgramFile{
+			{
+				Path:        "clicker.jar",
+				DownloadUrl: "https://buzkaaclicker.pl/demo/clicker.jar",
+				Hash:        "d6a4f5e7b8c9d0213445566778899001122ccddeeff00112233445566778899",
+			},
+			{
+				Path:        "config.yml",
+				DownloadUrl: "https://buzkaaclicker.pl/demo/config.yml",
+				Hash:        "e7b5a6f8c9d0e1324556677889900112233ddeeff0011223344556677889900",
+			},
+		}, files)
+	}
+
+	// seeding again leaves data untouched
+	assert.ErrorIs(SeedFixtures(ctx, db), ErrDatabaseNotEmpty)
+	count, err := db.NewSelect().Model((*Program)(nil)).Count(ctx)
+	if assert.NoError(err) {
+		programs, _ := fixturePrograms()
+		assert.Equal(len(programs), count)
+	}
+}
@@ -3,31 +3,67 @@ package persistent
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 
-	"github.com/sirupsen/logrus"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	_ "github.com/uptrace/bun/driver/pgdriver"
-	"github.com/uptrace/bun/extra/bundebug"
 )
 
-func PgOpen(ctx context.Context, pgDsn string) *bun.DB {
+type PgOptions struct {
+	// Applied to every session unless dsn sets statement_timeout itself. Zero disables it.
+	StatementTimeout time.Duration
+	// Queries running longer are logged as warnings. Zero disables it.
+	SlowQueryThreshold time.Duration
+}
+
+// Opens database and verifies connection with ping, so broken dsn fails here
+// instead of on first query.
+func PgOpen(ctx context.Context, pgDsn string, options PgOptions) (*bun.DB, error) {
+	if pgDsn == "" {
+		return nil, errors.New("empty dsn")
+	}
+	pgDsn, err := dsnWithStatementTimeout(pgDsn, options.StatementTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("statement timeout: %w", err)
+	}
 	sqldb, err := sql.Open("pg", pgDsn)
 	if err != nil {
-		logrus.WithError(err).Fatalln("Could not open pg database.")
+		return nil, fmt.Errorf("open: %w", err)
 	}
-	err = sqldb.Ping()
+	err = sqldb.PingContext(ctx)
 	if err != nil {
-		logrus.WithError(err).Fatalln("Could not ping pg database.")
+		_ = sqldb.Close()
+		return nil, fmt.Errorf("ping: %w", err)
 	}
 
 	bdb := bun.NewDB(sqldb, pgdialect.New())
-	if os.Getenv("DB_VERBOSE") == "true" {
-		// color.NoColor = false
-		bdb.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
+	// never add bundebug next to it, it logs queries verbatim with values this hook redacts
+	bdb.AddQueryHook(&QueryHook{SlowThreshold: options.SlowQueryThreshold})
+	return bdb, nil
+}
+
+// Sets postgres statement_timeout for every session opened with dsn, so runaway queries are
+// killed by server even if nobody cancels their context. Timeout already present in dsn wins.
+func dsnWithStatementTimeout(pgDsn string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return pgDsn, nil
+	}
+	u, err := url.Parse(pgDsn)
+	if err != nil {
+		return "", fmt.Errorf("parse dsn: %w", err)
 	}
-	return bdb
+	query := u.Query()
+	if query.Get("statement_timeout") == "" {
+		query.Set("statement_timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
 }
 
 // Running integration tests requires real pg db instance, but we
@@ -35,7 +71,11 @@ func PgOpen(ctx context.Context, pgDsn string) *bun.DB {
 // and then pass datasource to as many tests as we want.
 
 func PgOpenTest(ctx context.Context) *bun.DB {
-	return PgOpen(ctx, PgTestEnvDsn())
+	db, err := PgOpen(ctx, PgTestEnvDsn(), PgOptions{})
+	if err != nil {
+		panic(err)
+	}
+	return db
 }
 
 func PgTestEnvDsn() string {
@@ -44,6 +44,9 @@ func (s *ProfileStore) ByUserId(ctx context.Context, userId buzza.UserId) (buzza
 		Relation("User").
 		Where(`user_id=?`, userId).
 		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return buzza.Profile{}, buzza.ErrProfileNotFound
+	}
 	if err != nil {
 		return buzza.Profile{}, fmt.Errorf("select profile: %w", err)
 	}
@@ -59,14 +62,17 @@ func (c *ProfileController) ServeProfile(ctx *fiber.Ctx) error {
 	if userIdStr == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "no user id")
 	}
-	userId, err := strconv.ParseInt(userIdStr, 10, 0)
+	userId, err := strconv.ParseInt(userIdStr, 10, 64)
 	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return fiber.NewError(fiber.StatusBadRequest, "user id out of range")
+		}
 		return fiber.NewError(fiber.StatusBadRequest, "invalid user id")
 	}
 
 	profile, err := c.ProfileStore.ByUserId(ctx.Context(), buzza.UserId(userId))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, buzza.ErrProfileNotFound) {
 			return fiber.NewError(fiber.StatusNotFound, "profile not found")
 		} else {
 			return fmt.Errorf("get profile by user id: %w", err)
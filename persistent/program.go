@@ -44,15 +44,33 @@ type Program struct {
 	Files       []ProgramFile
 }
 
+func (p Program) ToDomain() buzza.Program {
+	files := make([]buzza.ProgramFile, len(p.Files))
+	for i, f := range p.Files {
+		files[i] = f.ToDomain()
+	}
+	return buzza.Program{
+		Id:     p.Id,
+		Type:   p.Type,
+		OS:     p.OS,
+		Arch:   p.Arch,
+		Branch: p.Branch,
+		Files:  files,
+	}
+}
+
 type ProgramStore struct {
 	DB *bun.DB
 }
 
+// Latest program is the most recently published one (created_at is bumped on promotion).
+// Unique build_type keeps single row per platform, so ordering only defines "latest"
+// should that constraint be relaxed, with higher id breaking ties.
 func (s ProgramStore) LatestProgramFiles(ctx context.Context, fileType string,
 	os string, arch string, branch string) ([]buzza.ProgramFile, error) {
 	subq := s.DB.NewSelect().
 		ColumnExpr("*").
-		ColumnExpr("row_number() over(partition by type, os, arch, branch order by id desc) as _row_number").
+		ColumnExpr("row_number() over(partition by type, os, arch, branch order by created_at desc, id desc) as _row_number").
 		Table("program").
 		Where("type=?", fileType).
 		Where("os=?", os).
@@ -74,7 +92,7 @@ func (s ProgramStore) LatestProgramFiles(ctx context.Context, fileType string,
 	case 0:
 		return nil, buzza.ErrProgramNotFound
 	case 1:
-		df := make([]buzza.ProgramFile, len(files))
+		df := make([]buzza.ProgramFile, len(files[0]))
 		for i, f := range files[0] {
 			df[i] = f.ToDomain()
 		}
@@ -83,3 +101,114 @@ func (s ProgramStore) LatestProgramFiles(ctx context.Context, fileType string,
 		return nil, fmt.Errorf("too many results (%d)", filesLen)
 	}
 }
+
+func (s ProgramStore) Platforms(ctx context.Context, fileType string) ([]buzza.ProgramPlatform, error) {
+	var platforms []buzza.ProgramPlatform
+	err := s.DB.NewSelect().
+		Model((*Program)(nil)).
+		Distinct().
+		Column("os", "arch", "branch").
+		Where("type=?", fileType).
+		Order("os", "arch", "branch").
+		Scan(ctx, &platforms)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return platforms, nil
+}
+
+func (s ProgramStore) Promote(ctx context.Context, programId int, branch string,
+	mode buzza.PromotionMode, promotedBy buzza.UserId) (buzza.Program, error) {
+	var promoted Program
+	err := withRetryTx(ctx, s.DB, func(ctx context.Context, tx bun.Tx) error {
+		source := new(Program)
+		err := tx.NewSelect().
+			Model(source).
+			Where("id=?", programId).
+			For("UPDATE").
+			Scan(ctx)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return buzza.ErrProgramNotFound
+			}
+			return fmt.Errorf("select source: %w", err)
+		}
+		if err := buzza.ValidatePromotion(source.Branch, branch); err != nil {
+			return err
+		}
+
+		if mode == buzza.PromotionMove {
+			promoted, err = movePromoted(ctx, tx, *source, branch)
+		} else {
+			promoted, err = clonePromoted(ctx, tx, *source, branch)
+		}
+		if err != nil {
+			return err
+		}
+
+		// in the same tx, so there is never promotion without audit entry
+		_, err = tx.NewInsert().
+			Model(&ActivityLog{
+				UserId: int64(promotedBy),
+				Name:   "program_promoted",
+				Data: map[string]interface{}{
+					"sourceProgramId": programId,
+					"programId":       promoted.Id,
+					"branch":          promoted.Branch,
+					"mode":            string(mode),
+				},
+			}).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("insert activity: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return buzza.Program{}, err
+	}
+	return promoted.ToDomain(), nil
+}
+
+func movePromoted(ctx context.Context, tx bun.Tx, source Program, branch string) (Program, error) {
+	// unique build_type counts soft deleted rows too, so replaced program has to go for good
+	_, err := tx.NewDelete().
+		Model((*Program)(nil)).
+		Where("type=? AND os=? AND arch=? AND branch=?", source.Type, source.OS, source.Arch, branch).
+		ForceDelete().
+		Exec(ctx)
+	if err != nil {
+		return Program{}, fmt.Errorf("delete target: %w", err)
+	}
+	_, err = tx.NewUpdate().
+		Model(&source).
+		Set("branch=?", branch).
+		Set("created_at=current_timestamp").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return Program{}, fmt.Errorf("move source: %w", err)
+	}
+	source.Branch = branch
+	return source, nil
+}
+
+func clonePromoted(ctx context.Context, tx bun.Tx, source Program, branch string) (Program, error) {
+	promoted := Program{
+		Type:   source.Type,
+		OS:     source.OS,
+		Arch:   source.Arch,
+		Branch: branch,
+		Files:  source.Files,
+	}
+	_, err := tx.NewInsert().
+		Model(&promoted).
+		On(`CONFLICT (type, os, arch, branch) DO UPDATE SET files=EXCLUDED.files, ` +
+			`created_at=current_timestamp, destroyed_at=NULL`).
+		Returning("id").
+		Exec(ctx)
+	if err != nil {
+		return Program{}, fmt.Errorf("upsert target: %w", err)
+	}
+	return promoted, nil
+}
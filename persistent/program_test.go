@@ -3,6 +3,7 @@ package persistent
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/buzkaaclicker/buzza"
 	"github.com/stretchr/testify/assert"
@@ -59,3 +60,171 @@ func TestProgramStore(t *testing.T) {
 		assert.Equal(c.expectedFiles, pf)
 	}
 }
+
+func TestProgramStoreLatestTiebreak(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	db := PgOpenTest(ctx)
+	defer db.Close()
+
+	// unique build_type rules out ties, so shadow program with temp table lacking it.
+	// Temp tables live in a session, keep every query on the same connection.
+	db.SetMaxOpenConns(1)
+	_, err := db.ExecContext(ctx, "CREATE TEMP TABLE program (LIKE program INCLUDING DEFAULTS)")
+	if !assert.NoError(err) {
+		return
+	}
+
+	createdAt := time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC)
+	// higher id inserted first, so physical order doesn't decide
+	_, err = db.NewInsert().Model(&[]Program{
+		{Id: 2, Type: "tiebreak", OS: "Windows", Arch: "x86-64", Branch: "stable", CreatedAt: createdAt,
+			Files: []ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/new", Hash: "2"}}},
+		{Id: 1, Type: "tiebreak", OS: "Windows", Arch: "x86-64", Branch: "stable", CreatedAt: createdAt,
+			Files: []ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/old", Hash: "1"}}},
+	}).Exec(ctx)
+	if !assert.NoError(err) {
+		return
+	}
+
+	store := ProgramStore{DB: db}
+	for i := 0; i < 5; i++ {
+		files, err := store.LatestProgramFiles(ctx, "tiebreak", "Windows", "x86-64", "stable")
+		if assert.NoError(err) {
+			assert.Equal([]buzza.ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/new", Hash: "2"}}, files)
+		}
+	}
+}
+
+func TestProgramStorePlatforms(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	db := PgOpenTest(ctx)
+	defer db.Close()
+
+	files := []ProgramFile{{Path: "agent.dll", DownloadUrl: "https://buzkaaclicker.pl/sample", Hash: "256"}}
+	_, err := db.NewInsert().Model(&[]Program{
+		{Type: "agent", OS: "Windows", Arch: "x86-64", Branch: "stable", Files: files},
+		{Type: "agent", OS: "Windows", Arch: "x86-64", Branch: "beta", Files: files},
+		{Type: "agent", OS: "Windows", Arch: "arm64", Branch: "stable", Files: files},
+		{Type: "agent", OS: "macOS", Arch: "arm64", Branch: "stable", Files: files},
+		{Type: "agent_other", OS: "Linux", Arch: "x86-64", Branch: "stable", Files: files},
+	}).Exec(ctx)
+	if !assert.NoError(err) {
+		return
+	}
+
+	store := ProgramStore{DB: db}
+	platforms, err := store.Platforms(ctx, "agent")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]buzza.ProgramPlatform{
+		{OS: "Windows", Arch: "arm64", Branch: "stable"},
+		{OS: "Windows", Arch: "x86-64", Branch: "beta"},
+		{OS: "Windows", Arch: "x86-64", Branch: "stable"},
+		{OS: "macOS", Arch: "arm64", Branch: "stable"},
+	}, platforms)
+}
+
+func TestProgramStorePromote(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	db := PgOpenTest(ctx)
+	defer db.Close()
+
+	betaFiles := []ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/beta", Hash: "2"}}
+	programs := []Program{
+		{Type: "promoted", OS: "Windows", Arch: "x86-64", Branch: "beta", Files: betaFiles},
+		{Type: "promoted", OS: "Windows", Arch: "x86-64", Branch: "stable",
+			Files: []ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/stable", Hash: "1"}}},
+	}
+	_, err := db.NewInsert().Model(&programs).Exec(ctx)
+	if !assert.NoError(err) {
+		return
+	}
+
+	store := ProgramStore{DB: db}
+	promoted, err := store.Promote(ctx, programs[0].Id, "stable", buzza.PromotionClone, 42)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(programs[1].Id, promoted.Id, "program published on target branch is replaced")
+	assert.Equal("stable", promoted.Branch)
+
+	files, err := store.LatestProgramFiles(ctx, "promoted", "Windows", "x86-64", "stable")
+	if assert.NoError(err) {
+		assert.Equal([]buzza.ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/beta", Hash: "2"}}, files)
+	}
+
+	_, err = store.Promote(ctx, programs[1].Id, "beta", buzza.PromotionClone, 42)
+	assert.ErrorIs(err, buzza.ErrInvalidPromotion)
+	_, err = store.Promote(ctx, -1, "stable", buzza.PromotionClone, 42)
+	assert.ErrorIs(err, buzza.ErrProgramNotFound)
+
+	logs, err := (&ActivityStore{DB: db}).ByUserId(ctx, 42)
+	if assert.NoError(err) && assert.Len(logs, 1, "only successful promotion is logged") {
+		assert.Equal("program_promoted", logs[0].Name)
+		assert.Equal("stable", logs[0].Data["branch"])
+		assert.Equal("clone", logs[0].Data["mode"])
+	}
+}
+
+func TestProgramStorePromoteMove(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+		return
+	}
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	db := PgOpenTest(ctx)
+	defer db.Close()
+
+	betaFiles := []ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/beta", Hash: "2"}}
+	programs := []Program{
+		{Type: "moved", OS: "Windows", Arch: "x86-64", Branch: "beta", Files: betaFiles},
+		{Type: "moved", OS: "Windows", Arch: "x86-64", Branch: "stable",
+			Files: []ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/stable", Hash: "1"}}},
+	}
+	_, err := db.NewInsert().Model(&programs).Exec(ctx)
+	if !assert.NoError(err) {
+		return
+	}
+
+	store := ProgramStore{DB: db}
+	promoted, err := store.Promote(ctx, programs[0].Id, "stable", buzza.PromotionMove, 43)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(programs[0].Id, promoted.Id, "program is moved in place")
+	assert.Equal("stable", promoted.Branch)
+
+	files, err := store.LatestProgramFiles(ctx, "moved", "Windows", "x86-64", "stable")
+	if assert.NoError(err) {
+		assert.Equal([]buzza.ProgramFile{{Path: "clicker.jar", DownloadUrl: "https://buzkaaclicker.pl/beta", Hash: "2"}}, files)
+	}
+	_, err = store.LatestProgramFiles(ctx, "moved", "Windows", "x86-64", "beta")
+	assert.ErrorIs(err, buzza.ErrProgramNotFound, "source branch is left empty")
+
+	logs, err := (&ActivityStore{DB: db}).ByUserId(ctx, 43)
+	if assert.NoError(err) && assert.Len(logs, 1) {
+		assert.Equal("program_promoted", logs[0].Name)
+		assert.Equal("move", logs[0].Data["mode"])
+	}
+}
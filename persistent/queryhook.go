@@ -0,0 +1,52 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/uptrace/bun"
+)
+
+var (
+	sqlStringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumericLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+)
+
+// Logs every query at debug level and warns about queries slower than SlowThreshold.
+// Values are redacted, so tokens and emails never end up in logs.
+type QueryHook struct {
+	// Zero disables slow query warnings.
+	SlowThreshold time.Duration
+}
+
+var _ bun.QueryHook = (*QueryHook)(nil)
+
+func (h *QueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *QueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	duration := time.Since(event.StartTime)
+	log := buzza.LogFromContext(ctx).
+		WithField("operation", event.Operation()).
+		WithField("duration", duration).
+		WithField("query", redactQuery(event.Query))
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		log = log.WithError(event.Err)
+	}
+
+	if h.SlowThreshold > 0 && duration >= h.SlowThreshold {
+		log.Warnln("Slow query.")
+	} else {
+		log.Debugln("Query executed.")
+	}
+}
+
+func redactQuery(query string) string {
+	query = sqlStringLiteral.ReplaceAllString(query, "'?'")
+	return sqlNumericLiteral.ReplaceAllString(query, "?")
+}
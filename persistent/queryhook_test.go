@@ -0,0 +1,74 @@
+package persistent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+)
+
+func TestQueryHookSlowQuery(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+	previousLevel := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	defer logrus.SetLevel(previousLevel)
+
+	hook := &QueryHook{SlowThreshold: 100 * time.Millisecond}
+	query := `SELECT "user"."id" FROM "user" WHERE (email='user@rol.es') AND (id=2137)`
+
+	hook.AfterQuery(context.Background(), &bun.QueryEvent{
+		Query:     query,
+		StartTime: time.Now(),
+	})
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal(logrus.DebugLevel, entry.Level)
+		assert.Equal("SELECT", entry.Data["operation"])
+		assert.Equal(`SELECT "user"."id" FROM "user" WHERE (email='?') AND (id=?)`, entry.Data["query"])
+	}
+
+	// pretend query has been running for a second
+	hook.AfterQuery(context.Background(), &bun.QueryEvent{
+		Query:     query,
+		StartTime: time.Now().Add(-time.Second),
+	})
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal(logrus.WarnLevel, entry.Level)
+		assert.Equal("Slow query.", entry.Message)
+		assert.GreaterOrEqual(entry.Data["duration"], time.Second)
+		assert.NotContains(entry.Data["query"], "user@rol.es")
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(`INSERT INTO "user" ("id", "discord_refresh_token") VALUES (DEFAULT, '?')`,
+		redactQuery(`INSERT INTO "user" ("id", "discord_refresh_token") VALUES (DEFAULT, 'it''s secret')`))
+	assert.Equal(`SELECT * FROM (?) AS t WHERE t._row_number = ?`,
+		redactQuery(`SELECT * FROM (?) AS t WHERE t._row_number = 1`))
+	assert.Equal(`SELECT t1.id FROM t1 WHERE price > ?`,
+		redactQuery(`SELECT t1.id FROM t1 WHERE price > 21.37`))
+}
+
+func TestQueryHookRequestLog(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	hook := &QueryHook{SlowThreshold: 100 * time.Millisecond}
+	ctx := buzza.ContextWithLog(context.Background(), logrus.WithField("request_id", "2137"))
+	hook.AfterQuery(ctx, &bun.QueryEvent{
+		Query:     `SELECT 1`,
+		StartTime: time.Now().Add(-time.Second),
+	})
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal("2137", entry.Data["request_id"])
+	}
+}
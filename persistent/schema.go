@@ -0,0 +1,54 @@
+package persistent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+// Every model stored in postgres, tables of them have to exist before server starts using database.
+var Models = []interface{}{
+	(*User)(nil),
+	(*ActivityLog)(nil),
+	(*Profile)(nil),
+	(*Program)(nil),
+	(*FeatureFlag)(nil),
+}
+
+// Fails when table of any model is missing in current schema,
+// which means migrations were not applied to database server is connected to.
+func CheckSchema(ctx context.Context, db *bun.DB) error {
+	expected := make([]string, 0, len(Models))
+	for _, model := range Models {
+		expected = append(expected, db.Table(reflect.TypeOf(model).Elem()).Name)
+	}
+
+	var existing []string
+	err := db.NewSelect().
+		TableExpr("information_schema.tables").
+		Column("table_name").
+		Where("table_schema = current_schema()").
+		Where("table_name IN (?)", bun.In(expected)).
+		Scan(ctx, &existing)
+	if err != nil {
+		return fmt.Errorf("select tables: %w", err)
+	}
+
+	existingSet := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		existingSet[name] = true
+	}
+	var missing []string
+	for _, name := range expected {
+		if !existingSet[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing tables: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -41,6 +41,8 @@ func (s Session) ToDomain() buzza.Session {
 type SessionStore struct {
 	Buntdb        *buntdb.DB
 	ActivityStore buzza.ActivityStore
+	// buzza.RealClock outside tests.
+	Clock buzza.Clock
 }
 
 func (s *SessionStore) CreateIndexes() {
@@ -66,14 +68,15 @@ func (s *SessionStore) RegisterNew(ctx context.Context, userId buzza.UserId, ip
 		return buzza.Session{}, fmt.Errorf("add logged in activity: %s", err)
 	}
 
+	now := s.Clock.Now().UTC()
 	session := Session{
 		Id:             id,
 		UserId:         int64(userId),
 		Token:          token,
 		Ip:             ip,
 		UserAgent:      userAgent,
-		LastAccessedAt: time.Now().UTC(),
-		ExpiresAt:      time.Now().UTC().Add(sessionTTL),
+		LastAccessedAt: now,
+		ExpiresAt:      now.Add(sessionTTL),
 	}
 	serializedSession, err := json.Marshal(&session)
 	if err != nil {
@@ -119,6 +122,9 @@ func (s *SessionStore) ByToken(token string) (buzza.Session, error) {
 			return buzza.Session{}, fmt.Errorf("buntdb view: %s", err)
 		}
 	}
+	if !s.Clock.Now().Before(session.ExpiresAt) {
+		return buzza.Session{}, buzza.ErrSessionNotFound
+	}
 	return session.ToDomain(), err
 }
 
@@ -200,12 +206,18 @@ func (s *SessionStore) AcquireAndRefresh(ctx context.Context, token string, ip s
 		}
 	}
 
+	// buntdb drops expired keys by wall clock, expiry is checked here as well so it follows store clock
+	now := s.Clock.Now().UTC()
+	if !now.Before(previousSession.ExpiresAt) {
+		return buzza.Session{}, buzza.ErrSessionNotFound
+	}
+
 	// copy session
 	session = previousSession
 	session.Ip = ip
 	session.UserAgent = userAgent
-	session.LastAccessedAt = time.Now().UTC()
-	session.ExpiresAt = time.Now().UTC().Add(sessionTTL)
+	session.LastAccessedAt = now
+	session.ExpiresAt = now.Add(sessionTTL)
 	serializedSession, err := json.Marshal(session)
 	if err != nil {
 		return buzza.Session{}, fmt.Errorf("serialize session: %s", err)
@@ -3,9 +3,11 @@ package persistent
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/buzkaaclicker/buzza"
 	"github.com/buzkaaclicker/buzza/inmem"
+	"github.com/buzkaaclicker/buzza/mock"
 	"github.com/stretchr/testify/assert"
 	"github.com/tidwall/buntdb"
 )
@@ -28,7 +30,7 @@ func TestSessionRegisterAndRefresh(t *testing.T) {
 	defer bdb.Close()
 
 	activityStore := inmem.NewActivityStore()
-	sessionStore := &SessionStore{Buntdb: bdb, ActivityStore: &activityStore}
+	sessionStore := &SessionStore{Buntdb: bdb, ActivityStore: &activityStore, Clock: buzza.RealClock{}}
 
 	session, err := sessionStore.RegisterNew(ctx, 9231982, "192.168.0.101", "Chrome/openBased")
 	if !assert.NoError(err) {
@@ -102,6 +104,48 @@ func TestSessionRegisterAndRefresh(t *testing.T) {
 	}
 }
 
+func TestSessionExpiry(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	bdb, err := buntdb.Open(":memory:")
+	if err != nil {
+		panic(err)
+	}
+	defer bdb.Close()
+
+	createdAt := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := mock.NewClock(createdAt)
+	activityStore := inmem.NewActivityStore()
+	sessionStore := &SessionStore{Buntdb: bdb, ActivityStore: &activityStore, Clock: clock}
+
+	session, err := sessionStore.RegisterNew(ctx, 1, "192.168.0.101", "Chrome/openBased")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(createdAt, session.LastAccessedAt)
+	assert.Equal(createdAt.Add(sessionTTL), session.ExpiresAt)
+
+	// refresh pushes expiry forward from the time of access
+	clock.Advance(sessionTTL - time.Hour)
+	refreshed, err := sessionStore.AcquireAndRefresh(ctx, session.Token, "192.168.0.101", "Chrome/openBased")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(clock.Now(), refreshed.LastAccessedAt)
+	assert.Equal(clock.Now().Add(sessionTTL), refreshed.ExpiresAt)
+
+	clock.Advance(sessionTTL - time.Second)
+	_, err = sessionStore.ByToken(session.Token)
+	assert.NoError(err)
+
+	clock.Advance(time.Second)
+	_, err = sessionStore.ByToken(session.Token)
+	assert.ErrorIs(err, buzza.ErrSessionNotFound)
+	_, err = sessionStore.AcquireAndRefresh(ctx, session.Token, "192.168.0.101", "Chrome/openBased")
+	assert.ErrorIs(err, buzza.ErrSessionNotFound)
+}
+
 func Test_GenerateSessionTokenLength(t *testing.T) {
 	assert := assert.New(t)
 
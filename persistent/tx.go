@@ -0,0 +1,58 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	txMaxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// Runs fn in a transaction and retries it, with jittered backoff, when postgres reports
+// serialization failure or deadlock. Those are safe to retry as whole transaction is rolled back.
+func withRetryTx(ctx context.Context, db *bun.DB, fn func(ctx context.Context, tx bun.Tx) error) error {
+	return retryOnSerializationFailure(ctx, txMaxAttempts, txRetryDelay, func() error {
+		return db.RunInTx(ctx, &sql.TxOptions{}, fn)
+	})
+}
+
+func retryOnSerializationFailure(ctx context.Context, attempts int, delay time.Duration, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			backoff := delay << (attempt - 1)
+			backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = fn()
+		if !isSerializationFailure(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func isSerializationFailure(err error) bool {
+	var pgErr interface{ Field(byte) string }
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	switch pgErr.Field('C') {
+	case "40001", "40P01": // serialization_failure, deadlock_detected
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,59 @@
+package persistent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePgError struct {
+	code string
+}
+
+func (e fakePgError) Field(k byte) string {
+	if k == 'C' {
+		return e.code
+	}
+	return ""
+}
+
+func (e fakePgError) Error() string {
+	return "SQLSTATE=" + e.code
+}
+
+func TestRetryOnSerializationFailure(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	calls := 0
+	err := retryOnSerializationFailure(ctx, 3, time.Millisecond, func() error {
+		calls++
+		if calls == 1 {
+			return fmt.Errorf("insert user: %w", fakePgError{code: "40001"})
+		}
+		return nil
+	})
+	assert.NoError(err)
+	assert.Equal(2, calls)
+
+	calls = 0
+	err = retryOnSerializationFailure(ctx, 3, time.Millisecond, func() error {
+		calls++
+		return fakePgError{code: "40P01"}
+	})
+	assert.Equal(fakePgError{code: "40P01"}, err)
+	assert.Equal(3, calls, "gives up after max attempts")
+
+	calls = 0
+	uniqueViolation := fakePgError{code: "23505"}
+	err = retryOnSerializationFailure(ctx, 3, time.Millisecond, func() error {
+		calls++
+		return uniqueViolation
+	})
+	assert.True(errors.Is(err, uniqueViolation))
+	assert.Equal(1, calls, "other errors are not retried")
+}
@@ -2,7 +2,6 @@ package persistent
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
@@ -63,7 +62,7 @@ func (s *UserStore) RegisterDiscordUser(ctx context.Context, u discord.User, ref
 		Email:               u.Email,
 	}
 
-	err := s.DB.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+	err := withRetryTx(ctx, s.DB, func(ctx context.Context, tx bun.Tx) error {
 		_, err := tx.NewInsert().
 			Model(user).
 			On(`CONFLICT (discord_id) DO UPDATE SET email=EXCLUDED.email, ` +
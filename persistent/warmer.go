@@ -0,0 +1,67 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Satisfied by *sql.DB and *bun.DB.
+type Pool interface {
+	PingContext(ctx context.Context) error
+	Stats() sql.DBStats
+}
+
+// Pings pool periodically so idle connections don't get dropped by server or proxies
+// after quiet periods and first requests don't pay for reconnecting.
+// Interval must be shorter than ConnMaxIdleTime of the pool, otherwise connections expire between pings.
+type PoolWarmer struct {
+	Pool Pool
+	// Number of connections kept warm, should match SetMaxIdleConns of the pool.
+	Conns    int
+	Interval time.Duration
+}
+
+// Blocks until ctx is cancelled.
+func (w *PoolWarmer) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.warm(ctx)
+		}
+	}
+}
+
+func (w *PoolWarmer) warm(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, w.Interval)
+	defer cancel()
+
+	// concurrent pings can't share connection, so each of them keeps another one busy
+	var wg sync.WaitGroup
+	for i := 0; i < w.Conns; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Pool.PingContext(ctx); err != nil && ctx.Err() == nil {
+				logrus.WithError(err).Warnln("Pool warmer ping failed.")
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := w.Pool.Stats()
+	logrus.
+		WithField("open", stats.OpenConnections).
+		WithField("in_use", stats.InUse).
+		WithField("idle", stats.Idle).
+		WithField("wait_count", stats.WaitCount).
+		WithField("max_idle_time_closed", stats.MaxIdleTimeClosed).
+		Debugln("Pool stats.")
+}
@@ -0,0 +1,52 @@
+package persistent
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePool struct {
+	pings int32
+}
+
+func (p *fakePool) PingContext(ctx context.Context) error {
+	atomic.AddInt32(&p.pings, 1)
+	return nil
+}
+
+func (p *fakePool) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
+
+func TestPoolWarmer(t *testing.T) {
+	assert := assert.New(t)
+
+	pool := &fakePool{}
+	warmer := PoolWarmer{Pool: pool, Conns: 2, Interval: 20 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		warmer.Run(ctx)
+		close(stopped)
+	}()
+
+	assert.Eventually(func() bool {
+		return atomic.LoadInt32(&pool.pings) >= 6
+	}, time.Second, 5*time.Millisecond, "warmer should ping conns every interval")
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		assert.Fail("warmer didn't stop on context cancel")
+		return
+	}
+	pings := atomic.LoadInt32(&pool.pings)
+	time.Sleep(3 * warmer.Interval)
+	assert.Equal(pings, atomic.LoadInt32(&pool.pings))
+}
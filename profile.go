@@ -4,6 +4,8 @@ import (
 	"context"
 )
 
+var ErrProfileNotFound = NewError(ErrNotFound, "profile not found")
+
 type Profile struct {
 	Id        int64
 	User      User
@@ -2,10 +2,54 @@ package buzza
 
 import (
 	"context"
-	"errors"
 )
 
-var ErrProgramNotFound = errors.New("program not found")
+var (
+	ErrProgramNotFound  = NewError(ErrNotFound, "program not found")
+	ErrUnknownBranch    = NewError(ErrValidation, "unknown branch")
+	ErrInvalidPromotion = NewError(ErrValidation, "program can be promoted only to more stable branch")
+)
+
+// Release branches ordered from the least to the most stable one.
+var ProgramBranches = []string{"alpha", "beta", "stable"}
+
+func IsProgramBranch(branch string) bool {
+	_, ok := branchRank(branch)
+	return ok
+}
+
+func branchRank(branch string) (int, bool) {
+	for i, b := range ProgramBranches {
+		if b == branch {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Checks whether build from one branch may be promoted to another.
+// Promotions never go towards less stable branch.
+func ValidatePromotion(from string, to string) error {
+	toRank, ok := branchRank(to)
+	if !ok {
+		return ErrUnknownBranch
+	}
+	fromRank, ok := branchRank(from)
+	if !ok || fromRank >= toRank {
+		return ErrInvalidPromotion
+	}
+	return nil
+}
+
+// How promoted program gets to target branch.
+type PromotionMode string
+
+const (
+	// Copy program to target branch, source branch keeps serving it too. Default.
+	PromotionClone PromotionMode = "clone"
+	// Move program to target branch, leaving source branch without program.
+	PromotionMove PromotionMode = "move"
+)
 
 type Program struct {
 	Id     int
@@ -26,8 +70,23 @@ type ProgramFile struct {
 	Hash string
 }
 
+// Platform with at least one published program.
+type ProgramPlatform struct {
+	OS     string
+	Arch   string
+	Branch string
+}
+
 type ProgramStore interface {
 	// Get latest program files matching specified arguments.
 	LatestProgramFiles(ctx context.Context, fileType string,
 		os string, arch string, branch string) ([]ProgramFile, error)
+
+	// Get distinct platforms with published programs of specified type.
+	Platforms(ctx context.Context, fileType string) ([]ProgramPlatform, error)
+
+	// Publish files of program to another branch, replacing program currently published there.
+	// Records program_promoted activity of promotedBy along with it, either both happen or neither.
+	// Returns program published to target branch.
+	Promote(ctx context.Context, programId int, branch string, mode PromotionMode, promotedBy UserId) (Program, error)
 }
@@ -0,0 +1,26 @@
+package buzza
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePromotion(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(ValidatePromotion("beta", "stable"))
+	assert.NoError(ValidatePromotion("alpha", "stable"))
+	assert.NoError(ValidatePromotion("alpha", "beta"))
+	assert.Equal(ErrInvalidPromotion, ValidatePromotion("stable", "beta"))
+	assert.Equal(ErrInvalidPromotion, ValidatePromotion("beta", "beta"))
+	assert.Equal(ErrInvalidPromotion, ValidatePromotion("custom", "stable"))
+	assert.Equal(ErrUnknownBranch, ValidatePromotion("beta", "nightly"))
+}
+
+func TestProgramKnownValues(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(IsProgramBranch("beta"))
+	assert.False(IsProgramBranch("nightly"))
+}
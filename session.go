@@ -2,11 +2,10 @@ package buzza
 
 import (
 	"context"
-	"errors"
 	"time"
 )
 
-var ErrSessionNotFound = errors.New("session not found")
+var ErrSessionNotFound = NewError(ErrNotFound, "session not found")
 
 type Session struct {
 	Id             string
@@ -0,0 +1,35 @@
+package rest
+
+import "github.com/gofiber/fiber/v2"
+
+// Build metadata of backend itself (not of served programs).
+type BuildInfo struct {
+	Commit    string
+	BuildTime string
+	GoVersion string
+}
+
+type AboutController struct {
+	Build BuildInfo
+}
+
+func (c *AboutController) InstallTo(app *fiber.App) {
+	app.Get("/", c.serveRoot)
+	app.Get("/about", c.serveAbout)
+}
+
+// Cheap liveness answer for monitors probing api root, never touches databases.
+func (c *AboutController) serveRoot(ctx *fiber.Ctx) error {
+	return ctx.JSON(map[string]string{
+		"service": "surfaceflinger-backend",
+		"status":  "ok",
+	})
+}
+
+func (c *AboutController) serveAbout(ctx *fiber.Ctx) error {
+	return ctx.JSON(map[string]string{
+		"commit":    c.Build.Commit,
+		"buildTime": c.Build.BuildTime,
+		"goVersion": c.Build.GoVersion,
+	})
+}
@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAboutController(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New()
+	controller := AboutController{
+		Build: BuildInfo{
+			Commit:    "4730516",
+			BuildTime: "2022-02-20T21:37:00Z",
+			GoVersion: "go1.17.7",
+		},
+	}
+	controller.InstallTo(app)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/about", nil))
+	if !assert.NoError(err) {
+		return
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(fiber.StatusOK, resp.StatusCode)
+	assert.Equal(`{"buildTime":"2022-02-20T21:37:00Z","commit":"4730516","goVersion":"go1.17.7"}`, string(body))
+}
+
+func TestAboutControllerRoot(t *testing.T) {
+	assert := assert.New(t)
+
+	server := fiber.New()
+	api := fiber.New()
+	controller := AboutController{}
+	controller.InstallTo(api)
+	server.Mount("/api/", api)
+
+	for _, path := range []string{"/api", "/api/"} {
+		resp, err := server.Test(httptest.NewRequest("GET", path, nil))
+		if !assert.NoError(err, path) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err, path)
+		assert.Equal(fiber.StatusOK, resp.StatusCode, path)
+		assert.Equal(fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType), path)
+		assert.Equal(`{"service":"surfaceflinger-backend","status":"ok"}`, string(body), path)
+	}
+}
@@ -20,11 +20,11 @@ func (c *ActivityController) lastActivityHandler(authorizationHandler fiber.Hand
 }
 
 func (c *ActivityController) serveLastActivity(ctx *fiber.Ctx) error {
-	user, ok := ctx.Locals(userLocalsKey).(*buzza.User)
+	user, ok := ctx.Locals(userLocalsKey).(buzza.User)
 	if !ok {
 		return fiber.ErrUnauthorized
 	}
-	logs, err := c.Store.ByUserId(ctx.Context(), user.Id)
+	logs, err := c.Store.ByUserId(requestContext(ctx), user.Id)
 	if err != nil {
 		return fmt.Errorf("get logs by user id: %w", err)
 	}
@@ -39,5 +39,12 @@ func (c *ActivityController) serveLastActivity(ctx *fiber.Ctx) error {
 	for i, log := range logs {
 		mapped[i] = Log{Id: log.Id, CreatedAt: log.CreatedAt.Unix(), Name: log.Name, Data: log.Data}
 	}
-	return ctx.JSON(mapped)
+
+	// ctx.JSON encodes into a buffer before touching the response, so on failure
+	// nothing is written yet and ErrorHandler replies with a clean 500.
+	err = ctx.JSON(mapped)
+	if err != nil {
+		return fmt.Errorf("json serialize: %w", err)
+	}
+	return nil
 }
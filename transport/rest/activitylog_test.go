@@ -8,9 +8,13 @@ import (
 	"time"
 
 	"github.com/buzkaaclicker/buzza"
+	"github.com/buzkaaclicker/buzza/discord"
+	"github.com/buzkaaclicker/buzza/inmem"
 	"github.com/buzkaaclicker/buzza/mock"
+	"github.com/buzkaaclicker/buzza/persistent"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/buntdb"
 )
 
 func TestActivityController(t *testing.T) {
@@ -51,7 +55,7 @@ func TestActivityController(t *testing.T) {
 		Store: store,
 	}
 	controller.InstallTo(func(ctx *fiber.Ctx) error {
-		ctx.Locals(userLocalsKey, &buzza.User{Id: 2})
+		ctx.Locals(userLocalsKey, buzza.User{Id: 2})
 		return nil
 	}, app)
 
@@ -70,3 +74,82 @@ func TestActivityController(t *testing.T) {
 		string(body))
 }
 
+func TestActivityControllerUnencodableData(t *testing.T) {
+	assert := assert.New(t)
+
+	store := &mock.ActivityStore{
+		ByUserIdFn: func(ctx context.Context, userId buzza.UserId) ([]buzza.ActivityLog, error) {
+			return []buzza.ActivityLog{
+				{
+					Id:     1,
+					UserId: 22,
+					Name:   "broken",
+					Data: map[string]interface{}{
+						"callback": func() {},
+					},
+				},
+			}, nil
+		},
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := ActivityController{
+		Store: store,
+	}
+	controller.InstallTo(func(ctx *fiber.Ctx) error {
+		ctx.Locals(userLocalsKey, buzza.User{Id: 22})
+		return nil
+	}, app)
+
+	req := httptest.NewRequest("GET", "/activities", nil)
+	resp, err := app.Test(req)
+	if !assert.NoError(err) {
+		return
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(fiber.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(JsonErrorMessageResponse(fiber.ErrInternalServerError.Message), string(body))
+}
+
+func TestActivityControllerWithRequestAuthorizer(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	bdb, err := buntdb.Open(":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer bdb.Close()
+
+	userStore := inmem.NewUserStore()
+	activityStore := inmem.NewActivityStore()
+	sessionStore := &persistent.SessionStore{Buntdb: bdb, ActivityStore: &activityStore, Clock: buzza.RealClock{}}
+	user, err := userStore.RegisterDiscordUser(ctx, discord.User{Id: "makin", Email: "makin"}, "")
+	if !assert.NoError(err) {
+		return
+	}
+	session, err := sessionStore.RegisterNew(ctx, user.Id, "192.168.0.101", "Chrome/openBased")
+	if !assert.NoError(err) {
+		return
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := ActivityController{Store: &activityStore}
+	controller.InstallTo(RequestAuthorizer(sessionStore, &userStore), app)
+
+	req := httptest.NewRequest("GET", "/activities", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+session.Token)
+	resp, err := app.Test(req)
+	if !assert.NoError(err) {
+		return
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(fiber.StatusOK, resp.StatusCode)
+	assert.Contains(string(body), `"name":"session_created"`)
+}
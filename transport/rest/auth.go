@@ -46,7 +46,7 @@ func (c *AuthController) serveAuthenticateDiscord(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, "invalid code")
 	}
 
-	exchange, err := c.ExchangeAccessToken(code)
+	exchange, err := c.ExchangeAccessToken(requestContext(ctx), code)
 	if err != nil {
 		if errors.Is(err, discord.ErrOAuthInvalidCode) {
 			return fiber.NewError(fiber.StatusUnauthorized, "invalid code")
@@ -55,7 +55,7 @@ func (c *AuthController) serveAuthenticateDiscord(ctx *fiber.Ctx) error {
 		}
 	}
 
-	dcUser, err := c.UserMeProvider()(exchange.Token())
+	dcUser, err := c.UserMeProvider()(requestContext(ctx), exchange.Token())
 	if err != nil {
 		return fmt.Errorf("discord user me: %w", err)
 	}
@@ -63,7 +63,7 @@ func (c *AuthController) serveAuthenticateDiscord(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "missing email")
 	}
 
-	guildAddStatus, err := c.GuildMemberAdd(exchange.AccessToken, dcUser.Id)
+	guildAddStatus, err := c.GuildMemberAdd(requestContext(ctx), exchange.AccessToken, dcUser.Id)
 	if err != nil {
 		if errors.Is(err, discord.ErrUnauthorized) {
 			return fiber.NewError(fiber.StatusUnauthorized, "discord guild join unauthorized")
@@ -73,13 +73,13 @@ func (c *AuthController) serveAuthenticateDiscord(ctx *fiber.Ctx) error {
 	}
 	requestLog(ctx).Infof("Discord guild member add status: %d\n", guildAddStatus)
 
-	dbCtx, cancelFunc := context.WithTimeout(context.Background(), time.Minute)
+	dbCtx, cancelFunc := context.WithTimeout(requestContext(ctx), time.Minute)
 	user, err := c.UserStore.RegisterDiscordUser(dbCtx, dcUser, exchange.RefreshToken)
 	cancelFunc()
 	if err != nil {
 		return fmt.Errorf("user register: %w", err)
 	}
-	session, err := c.SessionStore.RegisterNew(ctx.Context(), user.Id, ctx.IP(), string(ctx.Request().Header.UserAgent()))
+	session, err := c.SessionStore.RegisterNew(requestContext(ctx), user.Id, clientIP(ctx), string(ctx.Request().Header.UserAgent()))
 	if err != nil {
 		return fmt.Errorf("session register new: %w", err)
 	}
@@ -11,10 +11,12 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/buzkaaclicker/buzza"
 	"github.com/buzkaaclicker/buzza/discord"
 	"github.com/buzkaaclicker/buzza/inmem"
+	"github.com/buzkaaclicker/buzza/mock"
 	"github.com/buzkaaclicker/buzza/persistent"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -42,6 +44,7 @@ func Test_AuthLoginLogoutFlow(t *testing.T) {
 		SessionStore:   &persistent.SessionStore{
 			Buntdb:        bunt,
 			ActivityStore: &activityStore,
+			Clock:         buzza.RealClock{},
 		},
 		GuildMemberAdd: discord.MockGuildMemberAdd,
 	}
@@ -177,11 +180,11 @@ func Test_AuthLoginLogoutFlow(t *testing.T) {
 	}
 
 	caseTest := func(tc Case) {
-		authController.ExchangeAccessToken = func(code string) (discord.AccessTokenResponse, error) {
+		authController.ExchangeAccessToken = func(ctx context.Context, code string) (discord.AccessTokenResponse, error) {
 			return discord.AccessTokenResponse{}, tc.AccessTokenExchangeErr
 		}
 		authController.UserMeProvider = func() discord.UserMe {
-			return func(token discord.Token) (discord.User, error) {
+			return func(ctx context.Context, token discord.Token) (discord.User, error) {
 				return tc.User, tc.UserMeErr
 			}
 		}
@@ -197,6 +200,76 @@ func Test_AuthLoginLogoutFlow(t *testing.T) {
 	}
 }
 
+func Test_AuthRegisterRequestCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	bunt, err := buntdb.Open(":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer bunt.Close()
+
+	storeErr := make(chan error, 1)
+	var cancelRequest context.CancelFunc
+	activityStore := inmem.NewActivityStore()
+	authController := AuthController{
+		ExchangeAccessToken: func(ctx context.Context, code string) (discord.AccessTokenResponse, error) {
+			return discord.AccessTokenResponse{}, nil
+		},
+		UserMeProvider: func() discord.UserMe {
+			return func(ctx context.Context, token discord.Token) (discord.User, error) {
+				return discord.User{Email: "e@ma.il", Username: "cancelled", Id: "928592940128"}, nil
+			}
+		},
+		GuildMemberAdd: discord.MockGuildMemberAdd,
+		UserStore: mock.UserStore{
+			RegisterDiscordUserFn: func(ctx context.Context, u discord.User, refreshToken string) (buzza.User, error) {
+				// client goes away while user is being written
+				cancelRequest()
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+				storeErr <- ctx.Err()
+				return buzza.User{}, ctx.Err()
+			},
+		},
+		SessionStore: &persistent.SessionStore{Buntdb: bunt, ActivityStore: &activityStore, Clock: buzza.RealClock{}},
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(func(ctx *fiber.Ctx) error {
+		requestCtx, cancel := context.WithCancel(requestContext(ctx))
+		defer cancel()
+		cancelRequest = cancel
+		ctx.Locals(requestContextLocalsKey, requestCtx)
+		return ctx.Next()
+	})
+	authController.InstallTo(app)
+
+	req := httptest.NewRequest("POST", "/auth/discord", bytes.NewBuffer([]byte(`{"code": "21"}`)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(fiber.StatusInternalServerError, resp.StatusCode)
+
+	select {
+	case err := <-storeErr:
+		assert.ErrorIs(err, context.Canceled)
+	default:
+		assert.Fail("user store not called")
+	}
+	// registration failed, so no session may be created
+	err = bunt.View(func(tx *buntdb.Tx) error {
+		n, err := tx.Len()
+		assert.Zero(n)
+		return err
+	})
+	assert.NoError(err)
+}
+
 func Test_SessionAuthorization(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -220,6 +293,7 @@ func Test_SessionAuthorization(t *testing.T) {
 	sessionStore := &persistent.SessionStore{
 		Buntdb:        bdb,
 		ActivityStore: &activityStore,
+		Clock:         buzza.RealClock{},
 	}
 	controller := AuthController{
 		UserStore: &userStore,
@@ -314,7 +388,7 @@ func Test_SessionAuthorization(t *testing.T) {
 		},
 	}
 
-	controller.ExchangeAccessToken = func(code string) (discord.AccessTokenResponse, error) {
+	controller.ExchangeAccessToken = func(ctx context.Context, code string) (discord.AccessTokenResponse, error) {
 		return discord.AccessTokenResponse{RefreshToken: "mock_refresh_token"}, nil
 	}
 
@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const redactedValue = "[REDACTED]"
+
+type BodyLogConfig struct {
+	// Path prefixes of routes whose bodies are logged.
+	Routes []string
+	// Bodies longer than MaxSize bytes are truncated in logs.
+	MaxSize int
+	// Names of json fields masked in logs, matched case-insensitively at any depth.
+	RedactFields []string
+}
+
+// Logs request and response bodies of configured routes. Meant for diagnosing client
+// integrations, it does nothing unless logger is at debug level.
+func BodyLogHandler(config BodyLogConfig) fiber.Handler {
+	redactFields := make(map[string]bool, len(config.RedactFields))
+	for _, field := range config.RedactFields {
+		redactFields[strings.ToLower(field)] = true
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		if !logrus.IsLevelEnabled(logrus.DebugLevel) || !hasAnyPrefix(ctx.Path(), config.Routes) {
+			return ctx.Next()
+		}
+
+		// fasthttp keeps whole body in memory, reading it does not consume it for handlers
+		requestBody := loggableBody(ctx.Get(fiber.HeaderContentType), ctx.Body(), redactFields, config.MaxSize)
+		if err := ctx.Next(); err != nil {
+			// error body is written by error handler, run it here so it's the body that gets logged
+			if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+				_ = ctx.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+		responseBody := loggableBody(string(ctx.Response().Header.ContentType()), ctx.Response().Body(),
+			redactFields, config.MaxSize)
+		requestLog(ctx).
+			WithField("request_body", requestBody).
+			WithField("response_body", responseBody).
+			WithField("status", ctx.Response().StatusCode()).
+			Debugln("Request bodies.")
+		return nil
+	}
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Bodies other than json can't be redacted, only their size and hash are logged.
+func loggableBody(contentType string, body []byte, redactFields map[string]bool, maxSize int) string {
+	if len(body) > 0 && !isJSONContentType(contentType) {
+		return fmt.Sprintf("(%d bytes, sha256 %x)", len(body), sha256.Sum256(body))
+	}
+	var value interface{}
+	if len(redactFields) > 0 && json.Unmarshal(body, &value) == nil {
+		if redacted, err := json.Marshal(redactJSON(value, redactFields)); err == nil {
+			body = redacted
+		}
+	}
+	if maxSize > 0 && len(body) > maxSize {
+		return string(body[:maxSize]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == fiber.MIMEApplicationJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+func redactJSON(value interface{}, redactFields map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if redactFields[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactJSON(field, redactFields)
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactJSON(element, redactFields)
+		}
+	}
+	return value
+}
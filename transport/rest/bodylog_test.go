@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLogHandler(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+	previousLevel := logrus.GetLevel()
+	defer logrus.SetLevel(previousLevel)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(BodyLogHandler(BodyLogConfig{
+		Routes:       []string{"/auth"},
+		MaxSize:      64,
+		RedactFields: []string{"code", "accessToken"},
+	}))
+	app.Post("/auth/discord", func(ctx *fiber.Ctx) error {
+		received := string(ctx.Body())
+		if !strings.Contains(received, "secret-code") {
+			return fiber.NewError(fiber.StatusBadRequest, "body consumed before handler")
+		}
+		return ctx.JSON(map[string]interface{}{
+			"userId":      12,
+			"accessToken": "secret-token",
+		})
+	})
+	app.Post("/auth/echo", func(ctx *fiber.Ctx) error {
+		ctx.Type("json")
+		return ctx.Send(ctx.Body())
+	})
+	app.Post("/auth/fail", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid code")
+	})
+	app.Post("/auth/text", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("token=secret-token")
+	})
+	app.Post("/profile", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("{}")
+	})
+
+	requestWithType := func(path string, contentType string, body string) int {
+		logHook.Reset()
+		req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set(fiber.HeaderContentType, contentType)
+		resp, err := app.Test(req)
+		if !assert.NoError(err) {
+			return 0
+		}
+		return resp.StatusCode
+	}
+	request := func(path string, body string) int {
+		return requestWithType(path, fiber.MIMEApplicationJSON, body)
+	}
+
+	logrus.SetLevel(logrus.DebugLevel)
+	assert.Equal(fiber.StatusOK, request("/auth/discord", `{"code":"secret-code","state":{"code":"nested-code"}}`))
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal(`{"code":"[REDACTED]","state":{"code":"[REDACTED]"}}`, entry.Data["request_body"])
+		assert.Equal(`{"accessToken":"[REDACTED]","userId":12}`, entry.Data["response_body"])
+	}
+
+	long := `{"data":"` + strings.Repeat("a", 100) + `"}`
+	assert.Equal(fiber.StatusOK, request("/auth/echo", long))
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal(long[:64]+"...(truncated)", entry.Data["request_body"])
+		assert.Equal(long[:64]+"...(truncated)", entry.Data["response_body"])
+	}
+
+	// logged body is the one written by error handler
+	assert.Equal(fiber.StatusUnauthorized, request("/auth/fail", `{"code":"secret-code"}`))
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal(JsonErrorMessageResponse("invalid code"), entry.Data["response_body"])
+		assert.Equal(fiber.StatusUnauthorized, entry.Data["status"])
+	}
+
+	// bodies other than json can't be redacted, so they never reach logs
+	assert.Equal(fiber.StatusOK, requestWithType("/auth/text", fiber.MIMEApplicationForm, "code=secret-code"))
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal("(16 bytes, sha256 "+sha256Hex("code=secret-code")+")", entry.Data["request_body"])
+		assert.Equal("(18 bytes, sha256 "+sha256Hex("token=secret-token")+")", entry.Data["response_body"])
+	}
+
+	assert.Equal(fiber.StatusOK, request("/profile", `{"name":"ww_makin_c"}`))
+	assert.Nil(logHook.LastEntry(), "route not configured")
+
+	logrus.SetLevel(logrus.InfoLevel)
+	assert.Equal(fiber.StatusOK, request("/auth/discord", `{"code":"secret-code"}`))
+	assert.Nil(logHook.LastEntry(), "bodies logged outside of debug level")
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,18 @@
+package rest
+
+import "github.com/gofiber/fiber/v2"
+
+// Exposes counters of response caches, stale serves show how often store outages reach clients.
+type CacheMetricsController struct {
+	Programs *ProgramController
+}
+
+func (c *CacheMetricsController) InstallTo(app *fiber.App) {
+	app.Get("/metrics/cache", c.serveMetrics)
+}
+
+func (c *CacheMetricsController) serveMetrics(ctx *fiber.Ctx) error {
+	return ctx.JSON(map[string]map[string]uint64{
+		"platforms": {"staleServes": c.Programs.StaleServes()},
+	})
+}
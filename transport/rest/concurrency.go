@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Sheds requests above fixed number of concurrently handled ones,
+// so load spikes end with 503s instead of running out of memory.
+type ConcurrencyLimiter struct {
+	// Retry hint advertised with rejected requests.
+	RetryAfter time.Duration
+
+	slots chan struct{}
+}
+
+func NewConcurrencyLimiter(limit int, retryAfter time.Duration) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		RetryAfter: retryAfter,
+		slots:      make(chan struct{}, limit),
+	}
+}
+
+func (l *ConcurrencyLimiter) InstallTo(app *fiber.App) {
+	app.Get("/metrics/concurrency", l.serveMetrics)
+}
+
+func (l *ConcurrencyLimiter) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			return NewRetryAfterError(fiber.StatusServiceUnavailable, "server busy", l.RetryAfter)
+		}
+		defer func() { <-l.slots }()
+		return ctx.Next()
+	}
+}
+
+// Number of requests currently being handled.
+func (l *ConcurrencyLimiter) InFlight() int {
+	return len(l.slots)
+}
+
+func (l *ConcurrencyLimiter) serveMetrics(ctx *fiber.Ctx) error {
+	return ctx.JSON(map[string]int{
+		"inFlight": l.InFlight(),
+		"limit":    cap(l.slots),
+	})
+}
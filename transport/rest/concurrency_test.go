@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	const limit = 3
+	limiter := NewConcurrencyLimiter(limit, 2*time.Second)
+	release := make(chan struct{})
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(ExceptPaths(limiter.Handler(), "/metrics/concurrency"))
+	limiter.InstallTo(app)
+	app.Get("/slow", func(ctx *fiber.Ctx) error {
+		<-release
+		return ctx.SendString("done")
+	})
+
+	var wg sync.WaitGroup
+	statusCodes := make([]int, limit)
+	for i := 0; i < limit; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := app.Test(httptest.NewRequest("GET", "/slow", nil), -1)
+			if assert.NoError(err) {
+				statusCodes[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	for start := time.Now(); limiter.InFlight() < limit; time.Sleep(time.Millisecond) {
+		if time.Since(start) > time.Second {
+			assert.FailNow("requests did not saturate limiter")
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/slow", nil))
+	if assert.NoError(err) {
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		assert.Equal(fiber.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal("2", resp.Header.Get(fiber.HeaderRetryAfter))
+		assert.Equal(`{"error_message":"server busy","retry_after_seconds":2}`, string(body))
+	}
+
+	// exempt metrics still answer while saturated
+	resp, err = app.Test(httptest.NewRequest("GET", "/metrics/concurrency", nil))
+	if assert.NoError(err) {
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		assert.Equal(fiber.StatusOK, resp.StatusCode)
+		assert.Equal(`{"inFlight":3,"limit":3}`, string(body))
+	}
+
+	close(release)
+	wg.Wait()
+	for _, statusCode := range statusCodes {
+		assert.Equal(fiber.StatusOK, statusCode)
+	}
+	assert.Equal(0, limiter.InFlight())
+}
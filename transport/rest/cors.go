@@ -0,0 +1,16 @@
+package rest
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// Preflight responses are cached by browsers for maxAge, zero omits Access-Control-Max-Age.
+func CORSHandler(allowOrigins string, maxAge time.Duration) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOrigins: allowOrigins,
+		MaxAge:       int(maxAge.Seconds()),
+	})
+}
@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSHandlerMaxAge(t *testing.T) {
+	assert := assert.New(t)
+
+	preflight := func(maxAge time.Duration) (int, string) {
+		app := fiber.New()
+		app.Use(CORSHandler("https://buzkaaclicker.pl", maxAge))
+		app.Get("/profile", func(ctx *fiber.Ctx) error {
+			return ctx.SendString("profile")
+		})
+
+		req := httptest.NewRequest("OPTIONS", "/profile", nil)
+		req.Header.Set(fiber.HeaderOrigin, "https://buzkaaclicker.pl")
+		req.Header.Set(fiber.HeaderAccessControlRequestMethod, fiber.MethodGet)
+		resp, err := app.Test(req)
+		if !assert.NoError(err) {
+			return 0, ""
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderAccessControlMaxAge)
+	}
+
+	status, maxAge := preflight(5 * time.Minute)
+	assert.Equal(fiber.StatusNoContent, status)
+	assert.Equal("300", maxAge)
+
+	status, maxAge = preflight(0)
+	assert.Equal(fiber.StatusNoContent, status)
+	assert.Empty(maxAge)
+}
@@ -0,0 +1,63 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeprecatedRoute struct {
+	// Path prefix matching deprecated routes e.g. /api/download.
+	Prefix string
+	// Date after which route will be removed.
+	Sunset time.Time
+	// Migration hint for clients e.g. "use /api/v1/download".
+	Hint string
+}
+
+// Parses comma separated list of prefix|sunset|hint entries, sunset is date in YYYY-MM-DD format
+// e.g. "/api/download|2022-06-01|use /api/v1/download". Hints can't contain commas.
+func ParseDeprecatedRoutes(list string) ([]DeprecatedRoute, error) {
+	routes := make([]DeprecatedRoute, 0)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.SplitN(entry, "|", 3)
+		if len(fields) != 3 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid route: %s", entry)
+		}
+		sunset, err := time.Parse("2006-01-02", fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse sunset: %w", err)
+		}
+		routes = append(routes, DeprecatedRoute{Prefix: fields[0], Sunset: sunset, Hint: fields[2]})
+	}
+	return routes, nil
+}
+
+// Marks responses of deprecated routes with Deprecation, Sunset and Warning
+// headers, so we can nudge clients and track remaining legacy traffic.
+func DeprecationHandler(routes ...DeprecatedRoute) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		path := ctx.Path()
+		for _, route := range routes {
+			if !strings.HasPrefix(path, route.Prefix) {
+				continue
+			}
+			ctx.Set("Deprecation", "true")
+			ctx.Set("Sunset", route.Sunset.UTC().Format(http.TimeFormat))
+			ctx.Set(fiber.HeaderWarning, `299 - `+strconv.Quote("Deprecated API: "+route.Hint))
+			requestLog(ctx).
+				WithField("deprecated_route", route.Prefix).
+				Warnln("Deprecated route used.")
+			break
+		}
+		return ctx.Next()
+	}
+}
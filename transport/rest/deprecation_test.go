@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecationHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(DeprecationHandler(DeprecatedRoute{
+		Prefix: "/download",
+		Sunset: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC),
+		Hint:   "use /v1/download",
+	}))
+	app.Get("/download/installer", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("legacy")
+	})
+	app.Get("/v1/download/installer", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("current")
+	})
+
+	req := httptest.NewRequest("GET", "/download/installer", nil)
+	req.Header.Set(fiber.HeaderUserAgent, "BuzkaaClicker/1.0")
+	resp, err := app.Test(req)
+	if assert.NoError(err) {
+		assert.Equal(fiber.StatusOK, resp.StatusCode)
+		assert.Equal("true", resp.Header.Get("Deprecation"))
+		assert.Equal("Wed, 01 Jun 2022 00:00:00 GMT", resp.Header.Get("Sunset"))
+		assert.Equal(`299 - "Deprecated API: use /v1/download"`, resp.Header.Get(fiber.HeaderWarning))
+	}
+
+	req = httptest.NewRequest("GET", "/v1/download/installer", nil)
+	resp, err = app.Test(req)
+	if assert.NoError(err) {
+		assert.Equal(fiber.StatusOK, resp.StatusCode)
+		assert.Empty(resp.Header.Get("Deprecation"))
+		assert.Empty(resp.Header.Get("Sunset"))
+		assert.Empty(resp.Header.Get(fiber.HeaderWarning))
+	}
+}
+
+func TestParseDeprecatedRoutes(t *testing.T) {
+	assert := assert.New(t)
+
+	routes, err := ParseDeprecatedRoutes("/api/download|2022-06-01|use /api/v1/download, ,/api/profile|2022-07-15|use /api/v1/profile")
+	if assert.NoError(err) {
+		assert.Equal([]DeprecatedRoute{
+			{Prefix: "/api/download", Sunset: time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), Hint: "use /api/v1/download"},
+			{Prefix: "/api/profile", Sunset: time.Date(2022, 7, 15, 0, 0, 0, 0, time.UTC), Hint: "use /api/v1/profile"},
+		}, routes)
+	}
+
+	routes, err = ParseDeprecatedRoutes("")
+	if assert.NoError(err) {
+		assert.Equal(0, len(routes))
+	}
+
+	_, err = ParseDeprecatedRoutes("/api/download|2022-06-01")
+	assert.Error(err)
+	_, err = ParseDeprecatedRoutes("|2022-06-01|use /api/v1/download")
+	assert.Error(err)
+	_, err = ParseDeprecatedRoutes("/api/download|01.06.2022|use /api/v1/download")
+	assert.Error(err)
+}
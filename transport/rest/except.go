@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Operational endpoints polled by monitoring and load balancers, relative to api mount point.
+var operationalRoutes = []string{"/status", "/ready", "/health/details", "/metrics/concurrency", "/metrics/outbound", "/metrics/cache"}
+
+// Paths of operational endpoints when api is mounted at prefix e.g. "/api/".
+func OperationalPaths(prefix string) []string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	paths := make([]string, len(operationalRoutes))
+	for i, route := range operationalRoutes {
+		paths[i] = prefix + route
+	}
+	return paths
+}
+
+// Runs handler for every request except those to exact paths, which skip straight to the next handler.
+// Trailing slash is ignored, like in non-strict routing.
+// Use it to keep polled operational endpoints out of access logs and other noisy middleware.
+func ExceptPaths(handler fiber.Handler, paths ...string) fiber.Handler {
+	exempt := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		exempt[trimTrailingSlash(path)] = true
+	}
+	return func(ctx *fiber.Ctx) error {
+		if exempt[trimTrailingSlash(ctx.Path())] {
+			return ctx.Next()
+		}
+		return handler(ctx)
+	}
+}
+
+func trimTrailingSlash(path string) string {
+	if len(path) > 1 {
+		return strings.TrimSuffix(path, "/")
+	}
+	return path
+}
@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExceptPaths(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	requireKey := func(ctx *fiber.Ctx) error {
+		if ctx.Get("X-Api-Key") != "secret" {
+			return fiber.ErrUnauthorized
+		}
+		return ctx.Next()
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(RecoverHandler(false))
+	app.Use(ExceptPaths(LogHandler(), OperationalPaths("/api/")...))
+	app.Use(ExceptPaths(requireKey, OperationalPaths("/api/")...))
+	app.Get("/api/metrics/concurrency", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("metrics")
+	})
+	app.Get("/api/ready", func(ctx *fiber.Ctx) error {
+		panic("not ready")
+	})
+	app.Get("/api/about", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("version")
+	})
+
+	request := func(path string, apiKey string) int {
+		logHook.Reset()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-Api-Key", apiKey)
+		resp, err := app.Test(req)
+		if !assert.NoError(err) {
+			return 0
+		}
+		return resp.StatusCode
+	}
+
+	// metrics are scraped without key
+	assert.Equal(fiber.StatusOK, request("/api/metrics/concurrency", ""))
+	assert.Empty(logHook.AllEntries(), "operational request logged")
+	assert.Equal(fiber.StatusOK, request("/api/metrics/concurrency/", ""))
+	assert.Empty(logHook.AllEntries(), "trailing slash escaped exemption")
+
+	// exempt paths still run through recovery
+	assert.Equal(fiber.StatusInternalServerError, request("/api/ready", ""))
+	if entry := logHook.LastEntry(); assert.NotNil(entry) {
+		assert.Equal("Recovered from panic.", entry.Message)
+	}
+
+	assert.Equal(fiber.StatusUnauthorized, request("/api/about", ""))
+	assert.Len(logHook.AllEntries(), 1)
+	assert.Equal(fiber.StatusOK, request("/api/about", "secret"))
+	assert.Len(logHook.AllEntries(), 1)
+}
+
+func TestOperationalPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Contains(OperationalPaths("/api/"), "/api/metrics/concurrency")
+	assert.Equal(OperationalPaths("/api/"), OperationalPaths("/api"))
+	assert.Contains(OperationalPaths("/v2/api"), "/v2/api/ready")
+	assert.Contains(OperationalPaths(""), "/status")
+}
@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Flags are toggled by hand, minute of delay before clients see the change is fine.
+const featureFlagsCacheTTL = time.Minute
+
+// Match key and os column sizes.
+const (
+	featureFlagKeyMaxLength = 100
+	featureFlagOSMaxLength  = 30
+)
+
+// Os comes from clients as is, cache holds at most this many os and branch scopes.
+const featureFlagsCacheMaxEntries = 64
+
+type FeatureFlagController struct {
+	Store buzza.FeatureFlagStore
+	// buzza.RealClock outside tests.
+	Clock buzza.Clock
+
+	// Keyed by os and branch.
+	flagsCache ttlCache
+}
+
+func (c *FeatureFlagController) InstallTo(requestAuthorizer fiber.Handler, app *fiber.App) {
+	c.flagsCache.maxEntries = featureFlagsCacheMaxEntries
+	app.Get("/flags", c.serveFlags)
+	app.Put("/admin/flags/:key", combineHandlers(requestAuthorizer,
+		requirePermissions(buzza.PermissionAdminDashboard), c.servePut))
+}
+
+type featureFlagResponse struct {
+	Key     string          `json:"key"`
+	Enabled bool            `json:"enabled"`
+	Value   json.RawMessage `json:"value"`
+}
+
+func newFeatureFlagResponse(flag buzza.FeatureFlag) featureFlagResponse {
+	value := flag.Value
+	if value == nil {
+		value = json.RawMessage("null")
+	}
+	return featureFlagResponse{Key: flag.Key, Enabled: flag.Enabled, Value: value}
+}
+
+func (c *FeatureFlagController) serveFlags(ctx *fiber.Ctx) error {
+	os := ctx.Query("os")
+	branch := ctx.Query("branch", "stable")
+	if os == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "missing os")
+	}
+	if len(os) > featureFlagOSMaxLength {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid os")
+	}
+	if !buzza.IsProgramBranch(branch) {
+		return fiber.NewError(fiber.StatusBadRequest, "unknown branch")
+	}
+
+	flags, err := c.flags(ctx, os, branch)
+	if err != nil {
+		return fmt.Errorf("flags: %w", err)
+	}
+
+	mapped := make([]featureFlagResponse, len(flags))
+	for i, f := range flags {
+		mapped[i] = newFeatureFlagResponse(f)
+	}
+	return ctx.JSON(mapped)
+}
+
+func (c *FeatureFlagController) flags(ctx *fiber.Ctx, os string, branch string) ([]buzza.FeatureFlag, error) {
+	flags, _, err := c.flagsCache.get(requestContext(ctx), os+"\x00"+branch, featureFlagsCacheTTL, c.Clock.Now,
+		func(ctx context.Context) (interface{}, error) {
+			return c.Store.Applicable(ctx, os, branch)
+		})
+	if err != nil {
+		return nil, fmt.Errorf("repo applicable: %w", err)
+	}
+	return flags.([]buzza.FeatureFlag), nil
+}
+
+// Creates flag or replaces flag with the same key and scope.
+func (c *FeatureFlagController) servePut(ctx *fiber.Ctx) error {
+	body := struct {
+		Enabled *bool           `json:"enabled"`
+		Value   json.RawMessage `json:"value"`
+		OS      string          `json:"os"`
+		Branch  string          `json:"branch"`
+	}{}
+	if err := json.Unmarshal(ctx.Body(), &body); err != nil || body.Enabled == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	if string(body.Value) == "null" {
+		body.Value = nil
+	}
+	key := ctx.Params("key")
+	if key == "" || len(key) > featureFlagKeyMaxLength {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid key")
+	}
+	// empty os or branch scopes flag to every os or branch
+	if len(body.OS) > featureFlagOSMaxLength {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid os")
+	}
+	if body.Branch != "" && !buzza.IsProgramBranch(body.Branch) {
+		return fiber.NewError(fiber.StatusBadRequest, "unknown branch")
+	}
+
+	flag := buzza.FeatureFlag{
+		Key:     key,
+		Enabled: *body.Enabled,
+		Value:   body.Value,
+		OS:      body.OS,
+		Branch:  body.Branch,
+	}
+	if err := c.Store.Put(requestContext(ctx), flag); err != nil {
+		return fmt.Errorf("repo put: %w", err)
+	}
+	requestLog(ctx).
+		WithField("key", flag.Key).
+		WithField("enabled", flag.Enabled).
+		WithField("os", flag.OS).
+		WithField("branch", flag.Branch).
+		Infoln("Feature flag updated.")
+
+	// drop cached scopes, so change is visible immediately on this instance
+	c.flagsCache.invalidate()
+
+	return ctx.JSON(newFeatureFlagResponse(flag))
+}
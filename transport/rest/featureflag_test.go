@@ -0,0 +1,177 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/buzkaaclicker/buzza/mock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlagControllerFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	queries := 0
+	store := mock.FeatureFlagStore{
+		ApplicableFn: func(ctx context.Context, os string, branch string) ([]buzza.FeatureFlag, error) {
+			queries++
+			switch {
+			case os == "Windows" && branch == "beta":
+				return []buzza.FeatureFlag{
+					{Key: "cps_limit", Enabled: true, Value: []byte(`{"max":20}`), Branch: "beta"},
+					{Key: "new_ui", Enabled: true, OS: "Windows", Branch: "beta"},
+				}, nil
+			case os == "Windows" && branch == "stable":
+				return []buzza.FeatureFlag{{Key: "new_ui", Enabled: false}}, nil
+			default:
+				return nil, errors.New("db down")
+			}
+		},
+	}
+	controller := FeatureFlagController{Store: store, Clock: buzza.RealClock{}}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(func(ctx *fiber.Ctx) error { return nil }, app)
+
+	cases := []struct {
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/flags?os=Windows&branch=beta", fiber.StatusOK,
+			`[{"key":"cps_limit","enabled":true,"value":{"max":20}},{"key":"new_ui","enabled":true,"value":null}]`},
+		{"/flags?os=Windows", fiber.StatusOK, `[{"key":"new_ui","enabled":false,"value":null}]`},
+		{"/flags?branch=beta", fiber.StatusBadRequest, JsonErrorMessageResponse("missing os")},
+		{"/flags?os=macOS", fiber.StatusInternalServerError, JsonErrorMessageResponse(fiber.ErrInternalServerError.Message)},
+		{"/flags?os=" + strings.Repeat("o", featureFlagOSMaxLength+1), fiber.StatusBadRequest,
+			JsonErrorMessageResponse("invalid os")},
+		{"/flags?os=Windows&branch=nightly", fiber.StatusBadRequest, JsonErrorMessageResponse("unknown branch")},
+		// served from cache
+		{"/flags?os=Windows&branch=beta", fiber.StatusOK,
+			`[{"key":"cps_limit","enabled":true,"value":{"max":20}},{"key":"new_ui","enabled":true,"value":null}]`},
+	}
+	for _, c := range cases {
+		resp, err := app.Test(httptest.NewRequest("GET", c.path, nil))
+		if !assert.NoError(err, c.path) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err, c.path)
+		assert.Equal(c.expectedCode, resp.StatusCode, c.path)
+		assert.Equal(c.expectedBody, string(body), c.path)
+	}
+	assert.Equal(3, queries)
+}
+
+func TestFeatureFlagControllerPut(t *testing.T) {
+	assert := assert.New(t)
+
+	var stored []buzza.FeatureFlag
+	store := mock.FeatureFlagStore{
+		ApplicableFn: func(ctx context.Context, os string, branch string) ([]buzza.FeatureFlag, error) {
+			return buzza.ResolveFeatureFlags(stored), nil
+		},
+		PutFn: func(ctx context.Context, flag buzza.FeatureFlag) error {
+			stored = append(stored, flag)
+			return nil
+		},
+	}
+	user := buzza.User{Id: 1}
+	controller := FeatureFlagController{Store: store, Clock: buzza.RealClock{}}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(func(ctx *fiber.Ctx) error {
+		ctx.Locals(userLocalsKey, user)
+		return nil
+	}, app)
+
+	request := func(method string, path string, body string) (int, string) {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if !assert.NoError(err) {
+			return 0, ""
+		}
+		respBody, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		return resp.StatusCode, string(respBody)
+	}
+
+	status, _ := request("PUT", "/admin/flags/new_ui", `{"enabled":true}`)
+	assert.Equal(fiber.StatusUnauthorized, status)
+	assert.Empty(stored)
+
+	user.Roles = buzza.Roles{buzza.AllRoles[buzza.RoleIdAdmin]}
+	status, body := request("GET", "/flags?os=Windows", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(`[]`, body)
+
+	status, body = request("PUT", "/admin/flags/new_ui", `{"enabled":true,"value":{"theme":"dark"},"os":"Windows"}`)
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(`{"key":"new_ui","enabled":true,"value":{"theme":"dark"}}`, body)
+	assert.Equal([]buzza.FeatureFlag{
+		{Key: "new_ui", Enabled: true, Value: []byte(`{"theme":"dark"}`), OS: "Windows"},
+	}, stored)
+
+	// write invalidates cached flags
+	status, body = request("GET", "/flags?os=Windows", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(`[{"key":"new_ui","enabled":true,"value":{"theme":"dark"}}]`, body)
+
+	status, body = request("PUT", "/admin/flags/new_ui", `{"value":1}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+	assert.Equal(JsonErrorMessageResponse("invalid body"), body)
+
+	invalid := []struct {
+		path        string
+		body        string
+		expectedMsg string
+	}{
+		{"/admin/flags/" + strings.Repeat("k", featureFlagKeyMaxLength+1), `{"enabled":true}`, "invalid key"},
+		{"/admin/flags/new_ui", `{"enabled":true,"os":"` + strings.Repeat("o", featureFlagOSMaxLength+1) + `"}`, "invalid os"},
+		{"/admin/flags/new_ui", `{"enabled":true,"branch":"` + strings.Repeat("b", 256) + `"}`, "unknown branch"},
+	}
+	for _, c := range invalid {
+		status, body = request("PUT", c.path, c.body)
+		assert.Equal(fiber.StatusBadRequest, status, c.expectedMsg)
+		assert.Equal(JsonErrorMessageResponse(c.expectedMsg), body)
+	}
+	assert.Len(stored, 1)
+}
+
+func TestFeatureFlagControllerCacheExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	queries := 0
+	store := mock.FeatureFlagStore{
+		ApplicableFn: func(ctx context.Context, os string, branch string) ([]buzza.FeatureFlag, error) {
+			queries++
+			return []buzza.FeatureFlag{}, nil
+		},
+	}
+	clock := mock.NewClock(time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC))
+	controller := FeatureFlagController{Store: store, Clock: clock}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(func(ctx *fiber.Ctx) error { return nil }, app)
+
+	request := func() {
+		resp, err := app.Test(httptest.NewRequest("GET", "/flags?os=Windows", nil))
+		if assert.NoError(err) {
+			assert.Equal(fiber.StatusOK, resp.StatusCode)
+		}
+	}
+
+	request()
+	clock.Advance(featureFlagsCacheTTL - time.Second)
+	request()
+	assert.Equal(1, queries)
+	clock.Advance(time.Second)
+	request()
+	assert.Equal(2, queries)
+}
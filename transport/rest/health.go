@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const defaultHealthCheckTimeout = 2 * time.Second
+
+type HealthCheck struct {
+	Name string
+	// Failing critical check makes whole backend unhealthy.
+	Critical bool
+	// Defaults to defaultHealthCheckTimeout.
+	Timeout time.Duration
+	Check   func(ctx context.Context) error
+}
+
+type HealthController struct {
+	Checks []HealthCheck
+
+	// 1 when backend accepts new requests, accessed atomically.
+	ready int32
+}
+
+func (c *HealthController) InstallTo(app *fiber.App) {
+	app.Get("/ready", c.serveReady)
+	app.Get("/health/details", c.serveHealthDetails)
+}
+
+func (c *HealthController) SetReady(ready bool) {
+	var value int32
+	if ready {
+		value = 1
+	}
+	atomic.StoreInt32(&c.ready, value)
+}
+
+func (c *HealthController) Ready() bool {
+	return atomic.LoadInt32(&c.ready) == 1
+}
+
+func (c *HealthController) serveReady(ctx *fiber.Ctx) error {
+	if !c.Ready() {
+		return fiber.ErrServiceUnavailable
+	}
+	return ctx.JSON(map[string]bool{
+		"ready": true,
+	})
+}
+
+func (c *HealthController) serveHealthDetails(ctx *fiber.Ctx) error {
+	type CheckResult struct {
+		Status string `json:"status"`
+	}
+	type Response struct {
+		Status string                 `json:"status"`
+		Checks map[string]CheckResult `json:"checks"`
+	}
+
+	// fiber ctx must not be touched from check goroutines
+	checkCtx := requestContext(ctx)
+	errs := make([]error, len(c.Checks))
+	var wg sync.WaitGroup
+	for i, check := range c.Checks {
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			errs[i] = runHealthCheck(checkCtx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	response := Response{Status: "ok", Checks: make(map[string]CheckResult, len(c.Checks))}
+	statusCode := fiber.StatusOK
+	for i, check := range c.Checks {
+		err := errs[i]
+		if err == nil {
+			response.Checks[check.Name] = CheckResult{Status: "ok"}
+			continue
+		}
+		// endpoint is public without separate metrics listener, driver errors stay in logs
+		requestLog(ctx).
+			WithError(err).
+			WithField("check", check.Name).
+			WithField("critical", check.Critical).
+			Warnln("Health check failing.")
+		response.Checks[check.Name] = CheckResult{Status: "failing"}
+		if check.Critical {
+			response.Status = "unhealthy"
+			statusCode = fiber.StatusServiceUnavailable
+		} else if response.Status == "ok" {
+			response.Status = "degraded"
+		}
+	}
+	return ctx.Status(statusCode).JSON(response)
+}
+
+// Runs check with its own timeout. Checks ignoring context are abandoned after timeout.
+func runHealthCheck(ctx context.Context, check HealthCheck) error {
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- check.Check(ctx)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
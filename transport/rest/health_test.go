@@ -0,0 +1,146 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessBeforeShutdown(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := HealthController{}
+	controller.InstallTo(app)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(err) {
+		return
+	}
+	go app.Listener(ln)
+	url := "http://" + ln.Addr().String() + "/ready"
+
+	get := func() (int, string, error) {
+		resp, err := http.Get(url)
+		if err != nil {
+			return 0, "", err
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), err
+	}
+
+	controller.SetReady(true)
+	status, body, err := get()
+	if assert.NoError(err) {
+		assert.Equal(fiber.StatusOK, status)
+		assert.Equal(`{"ready":true}`, body)
+	}
+
+	// readiness flip must be visible while server still accepts connections
+	controller.SetReady(false)
+	status, body, err = get()
+	if assert.NoError(err) {
+		assert.Equal(fiber.StatusServiceUnavailable, status)
+		assert.Equal(JsonErrorMessageResponse(fiber.ErrServiceUnavailable.Message), body)
+	}
+
+	if !assert.NoError(app.Shutdown()) {
+		return
+	}
+	_, _, err = get()
+	assert.Error(err)
+}
+
+func TestHealthDetails(t *testing.T) {
+	assert := assert.New(t)
+
+	passing := func(ctx context.Context) error {
+		return nil
+	}
+	failing := func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}
+	hanging := func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}
+
+	cases := []struct {
+		checks     []HealthCheck
+		statusCode int
+		body       string
+	}{
+		{
+			checks: []HealthCheck{
+				{Name: "postgres", Critical: true, Check: passing},
+				{Name: "buntdb", Critical: true, Check: passing},
+			},
+			statusCode: fiber.StatusOK,
+			body:       `{"status":"ok","checks":{"buntdb":{"status":"ok"},"postgres":{"status":"ok"}}}`,
+		},
+		{
+			checks: []HealthCheck{
+				{Name: "postgres", Critical: true, Check: failing},
+				{Name: "buntdb", Critical: true, Check: passing},
+			},
+			statusCode: fiber.StatusServiceUnavailable,
+			body: `{"status":"unhealthy","checks":{"buntdb":{"status":"ok"},` +
+				`"postgres":{"status":"failing"}}}`,
+		},
+		{
+			checks: []HealthCheck{
+				{Name: "postgres", Critical: true, Check: passing},
+				{Name: "discord", Check: failing},
+			},
+			statusCode: fiber.StatusOK,
+			body: `{"status":"degraded","checks":{"discord":{"status":"failing"},` +
+				`"postgres":{"status":"ok"}}}`,
+		},
+		{
+			checks: []HealthCheck{
+				{Name: "postgres", Critical: true, Timeout: 10 * time.Millisecond, Check: hanging},
+			},
+			statusCode: fiber.StatusServiceUnavailable,
+			body:       `{"status":"unhealthy","checks":{"postgres":{"status":"failing"}}}`,
+		},
+	}
+
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+	for i, tc := range cases {
+		logHook.Reset()
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		controller := HealthController{Checks: tc.checks}
+		controller.InstallTo(app)
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/health/details", nil))
+		if !assert.NoError(err, "index: %d", i) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if !assert.NoError(err, "index: %d", i) {
+			continue
+		}
+		assert.Equal(tc.statusCode, resp.StatusCode, "index: %d", i)
+		assert.Equal(tc.body, string(body), "index: %d", i)
+		assert.NotContains(string(body), "connection refused", "index: %d", i)
+		if strings.Contains(tc.body, "failing") {
+			if entry := logHook.LastEntry(); assert.NotNil(entry, "index: %d", i) {
+				assert.Equal("Health check failing.", entry.Message)
+				assert.Error(entry.Data[logrus.ErrorKey].(error))
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const clientIpLocalsKey = "client_ip"
+
+// Parses comma separated list of CIDRs e.g. "10.0.0.0/8, 192.168.1.1".
+// Plain ips are treated as single address networks.
+func ParseTrustedProxies(list string) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip: %s", entry)
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("parse cidr: %w", err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Resolves real client ip and stores it for clientIP. Forwarding headers
+// are only honored when request comes from one of trusted proxies.
+func ClientIPHandler(trustedProxies []*net.IPNet) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		ctx.Locals(clientIpLocalsKey, resolveClientIP(ctx, trustedProxies))
+		return ctx.Next()
+	}
+}
+
+// Single source of client ip. Use it instead of ctx.IP().
+func clientIP(ctx *fiber.Ctx) string {
+	if ip, ok := ctx.Locals(clientIpLocalsKey).(string); ok {
+		return ip
+	}
+	return ctx.IP()
+}
+
+func resolveClientIP(ctx *fiber.Ctx, trustedProxies []*net.IPNet) string {
+	remoteIp := ctx.Context().RemoteIP()
+	if !isTrustedProxy(remoteIp, trustedProxies) {
+		return remoteIp.String()
+	}
+
+	// every proxy appends address of its peer, so walk from the right
+	// and stop at first hop we don't trust.
+	if forwardedFor := ctx.Get(fiber.HeaderXForwardedFor); forwardedFor != "" {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(hops[i]))
+			if ip == nil {
+				break
+			}
+			if !isTrustedProxy(ip, trustedProxies) {
+				return ip.String()
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(ctx.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return remoteIp.String()
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
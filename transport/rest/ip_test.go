@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	assert := assert.New(t)
+
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.1,,::1")
+	if assert.NoError(err) && assert.Equal(3, len(proxies)) {
+		assert.Equal("10.0.0.0/8", proxies[0].String())
+		assert.Equal("192.168.1.1/32", proxies[1].String())
+		assert.Equal("::1/128", proxies[2].String())
+	}
+
+	proxies, err = ParseTrustedProxies("")
+	if assert.NoError(err) {
+		assert.Equal(0, len(proxies))
+	}
+
+	_, err = ParseTrustedProxies("10.0.0.0/8,proxy.local")
+	assert.Error(err)
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(err)
+}
+
+func TestClientIP(t *testing.T) {
+	assert := assert.New(t)
+
+	// app.Test connections come from 0.0.0.0
+	trustedRemote, err := ParseTrustedProxies("0.0.0.0, 10.0.0.0/8")
+	if !assert.NoError(err) {
+		return
+	}
+	untrustedRemote, err := ParseTrustedProxies("10.0.0.0/8")
+	if !assert.NoError(err) {
+		return
+	}
+
+	cases := []struct {
+		name         string
+		proxies      string
+		forwardedFor string
+		realIp       string
+		expected     string
+	}{
+		{"no proxies", "", "1.2.3.4", "5.6.7.8", "0.0.0.0"},
+		{"untrusted remote spoofing", "untrusted", "1.2.3.4", "5.6.7.8", "0.0.0.0"},
+		{"trusted remote", "trusted", "1.2.3.4", "", "1.2.3.4"},
+		{"trusted remote with proxy chain", "trusted", "1.2.3.4, 10.0.0.2, 10.0.0.1", "", "1.2.3.4"},
+		{"client spoofing forwarded for", "trusted", "6.6.6.6, 1.2.3.4, 10.0.0.1", "", "1.2.3.4"},
+		{"garbage hop", "trusted", "1.2.3.4, garbage", "", "0.0.0.0"},
+		{"real ip fallback", "trusted", "", "5.6.7.8", "5.6.7.8"},
+		{"only trusted hops", "trusted", "10.0.0.2", "", "0.0.0.0"},
+	}
+
+	for _, tc := range cases {
+		app := fiber.New()
+		switch tc.proxies {
+		case "trusted":
+			app.Use(ClientIPHandler(trustedRemote))
+		case "untrusted":
+			app.Use(ClientIPHandler(untrustedRemote))
+		default:
+			app.Use(ClientIPHandler(nil))
+		}
+		app.Get("/", func(ctx *fiber.Ctx) error {
+			return ctx.SendString(clientIP(ctx))
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		if tc.forwardedFor != "" {
+			req.Header.Set(fiber.HeaderXForwardedFor, tc.forwardedFor)
+		}
+		if tc.realIp != "" {
+			req.Header.Set("X-Real-IP", tc.realIp)
+		}
+		resp, err := app.Test(req)
+		if !assert.NoError(err, tc.name) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if !assert.NoError(err, tc.name) {
+			continue
+		}
+		assert.Equal(tc.expected, string(body), tc.name)
+	}
+}
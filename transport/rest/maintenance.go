@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type MaintenanceController struct {
+	// Retry hint advertised with rejected writes.
+	RetryAfter time.Duration
+
+	// 1 when maintenance mode is enabled, accessed atomically.
+	enabled int32
+}
+
+// Toggle routes must be installed before Handler, otherwise maintenance mode
+// could never be disabled through the api.
+func (c *MaintenanceController) InstallTo(requestAuthorizer fiber.Handler, app *fiber.App) {
+	authorize := combineHandlers(requestAuthorizer, requirePermissions(buzza.PermissionAdminDashboard))
+	app.Get("/admin/maintenance", combineHandlers(authorize, c.serveStatus))
+	app.Put("/admin/maintenance", combineHandlers(authorize, c.serveToggle))
+}
+
+func (c *MaintenanceController) SetEnabled(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	if atomic.SwapInt32(&c.enabled, value) != value {
+		logrus.WithField("enabled", enabled).Warnln("Maintenance mode changed.")
+	}
+}
+
+func (c *MaintenanceController) Enabled() bool {
+	return atomic.LoadInt32(&c.enabled) == 1
+}
+
+// Rejects mutating requests while maintenance mode is enabled. Reads are served as usual.
+func (c *MaintenanceController) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if c.Enabled() && isMutatingMethod(ctx.Method()) {
+			return NewRetryAfterError(fiber.StatusServiceUnavailable, "maintenance", c.RetryAfter)
+		}
+		return ctx.Next()
+	}
+}
+
+func (c *MaintenanceController) serveStatus(ctx *fiber.Ctx) error {
+	return ctx.JSON(map[string]bool{
+		"enabled": c.Enabled(),
+	})
+}
+
+func (c *MaintenanceController) serveToggle(ctx *fiber.Ctx) error {
+	body := struct {
+		Enabled *bool `json:"enabled"`
+	}{}
+	if err := ctx.BodyParser(&body); err != nil || body.Enabled == nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+	c.SetEnabled(*body.Enabled)
+	return c.serveStatus(ctx)
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,74 @@
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	assert := assert.New(t)
+
+	controller := MaintenanceController{RetryAfter: time.Minute}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(func(ctx *fiber.Ctx) error {
+		ctx.Locals(userLocalsKey, buzza.User{Roles: buzza.Roles{buzza.AllRoles[buzza.RoleIdAdmin]}})
+		return nil
+	}, app)
+	app.Use(controller.Handler())
+	app.Get("/resource", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("read")
+	})
+	app.Post("/resource", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("written")
+	})
+
+	request := func(method string, path string, body string) (int, string, string) {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if !assert.NoError(err) {
+			return 0, "", ""
+		}
+		respBody, err := ioutil.ReadAll(resp.Body)
+		if !assert.NoError(err) {
+			return 0, "", ""
+		}
+		return resp.StatusCode, resp.Header.Get(fiber.HeaderRetryAfter), string(respBody)
+	}
+
+	status, _, body := request("POST", "/resource", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal("written", body)
+
+	status, _, body = request("PUT", "/admin/maintenance", `{"enabled":true}`)
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(`{"enabled":true}`, body)
+	assert.True(controller.Enabled())
+
+	status, retryAfter, body := request("POST", "/resource", "")
+	assert.Equal(fiber.StatusServiceUnavailable, status)
+	assert.Equal("60", retryAfter)
+	assert.Equal(`{"error_message":"maintenance","retry_after_seconds":60}`, body)
+
+	status, _, body = request("GET", "/resource", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal("read", body)
+
+	status, _, _ = request("PUT", "/admin/maintenance", `{}`)
+	assert.Equal(fiber.StatusBadRequest, status)
+
+	status, _, body = request("PUT", "/admin/maintenance", `{"enabled":false}`)
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal(`{"enabled":false}`, body)
+
+	status, _, body = request("POST", "/resource", "")
+	assert.Equal(fiber.StatusOK, status)
+	assert.Equal("written", body)
+}
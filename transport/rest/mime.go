@@ -0,0 +1,31 @@
+package rest
+
+import (
+	"mime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rejects body-bearing requests which aren't application/json (charset parameter is allowed),
+// so form posts or plain text never reach body parsers.
+func RequireJSONHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if !isBodyMethod(ctx.Method()) || len(ctx.Body()) == 0 {
+			return ctx.Next()
+		}
+		mediaType, _, err := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+		if err != nil || mediaType != fiber.MIMEApplicationJSON {
+			return fiber.ErrUnsupportedMediaType
+		}
+		return ctx.Next()
+	}
+}
+
+func isBodyMethod(method string) bool {
+	switch method {
+	case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch:
+		return true
+	default:
+		return false
+	}
+}
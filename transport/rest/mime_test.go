@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireJSONHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(RequireJSONHandler())
+	handler := func(ctx *fiber.Ctx) error {
+		return ctx.SendString("ok")
+	}
+	app.Post("/resource", handler)
+	app.Get("/resource", handler)
+	app.Delete("/resource", handler)
+
+	cases := []struct {
+		method      string
+		contentType string
+		body        string
+		statusCode  int
+	}{
+		{"POST", fiber.MIMEApplicationJSON, `{"code":"21"}`, fiber.StatusOK},
+		{"POST", fiber.MIMEApplicationJSONCharsetUTF8, `{"code":"21"}`, fiber.StatusOK},
+		{"POST", "Application/JSON; charset=utf-8", `{"code":"21"}`, fiber.StatusOK},
+		{"POST", fiber.MIMEApplicationForm, `code=21`, fiber.StatusUnsupportedMediaType},
+		{"POST", fiber.MIMETextPlain, `{"code":"21"}`, fiber.StatusUnsupportedMediaType},
+		{"POST", "", `{"code":"21"}`, fiber.StatusUnsupportedMediaType},
+		{"POST", "application/json-patch+json", `[]`, fiber.StatusUnsupportedMediaType},
+		// bodyless requests are exempt
+		{"POST", "", ``, fiber.StatusOK},
+		{"GET", fiber.MIMETextPlain, ``, fiber.StatusOK},
+		{"DELETE", "", ``, fiber.StatusOK},
+	}
+	for i, tc := range cases {
+		req := httptest.NewRequest(tc.method, "/resource", bytes.NewBufferString(tc.body))
+		if tc.contentType != "" {
+			req.Header.Set(fiber.HeaderContentType, tc.contentType)
+		}
+		resp, err := app.Test(req)
+		if !assert.NoError(err, "index: %d", i) {
+			continue
+		}
+		assert.Equal(tc.statusCode, resp.StatusCode, "index: %d", i)
+		if tc.statusCode == fiber.StatusUnsupportedMediaType {
+			body, err := ioutil.ReadAll(resp.Body)
+			if assert.NoError(err) {
+				assert.Equal(JsonErrorMessageResponse(fiber.ErrUnsupportedMediaType.Message), string(body))
+			}
+		}
+	}
+}
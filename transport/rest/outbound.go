@@ -0,0 +1,21 @@
+package rest
+
+import (
+	"github.com/buzkaaclicker/buzza/discord"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Exposes outbound request counters of shared http clients.
+type OutboundMetricsController struct {
+	Discord *discord.Client
+}
+
+func (c *OutboundMetricsController) InstallTo(app *fiber.App) {
+	app.Get("/metrics/outbound", c.serveMetrics)
+}
+
+func (c *OutboundMetricsController) serveMetrics(ctx *fiber.Ctx) error {
+	return ctx.JSON(map[string]discord.ClientStats{
+		"discord": c.Discord.Stats(),
+	})
+}
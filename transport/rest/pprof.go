@@ -0,0 +1,21 @@
+package rest
+
+import (
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
+)
+
+// Serves pprof to admins, for deployments without separate internal listener.
+// Must be installed to the root app, pprof matches on full request path.
+type PprofController struct{}
+
+func (c *PprofController) InstallTo(requestAuthorizer fiber.Handler, app *fiber.App) {
+	authorize := combineHandlers(requestAuthorizer, requirePermissions(buzza.PermissionAdminDashboard))
+	app.Use("/debug/pprof", func(ctx *fiber.Ctx) error {
+		if err := authorize(ctx); err != nil {
+			return err
+		}
+		return ctx.Next()
+	}, pprof.New())
+}
@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPprofController(t *testing.T) {
+	assert := assert.New(t)
+
+	user := buzza.User{Id: 1}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := PprofController{}
+	controller.InstallTo(func(ctx *fiber.Ctx) error {
+		ctx.Locals(userLocalsKey, user)
+		return nil
+	}, app)
+	app.Get("/other", func(ctx *fiber.Ctx) error {
+		return ctx.SendString("other")
+	})
+
+	status := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if !assert.NoError(err, path) {
+			return 0
+		}
+		return resp.StatusCode
+	}
+
+	assert.Equal(fiber.StatusUnauthorized, status("/debug/pprof/"))
+	assert.Equal(fiber.StatusUnauthorized, status("/debug/pprof/heap"))
+	assert.Equal(fiber.StatusOK, status("/other"))
+
+	user.Roles = buzza.Roles{buzza.AllRoles[buzza.RoleIdAdmin]}
+	assert.Equal(fiber.StatusOK, status("/debug/pprof/"))
+	assert.Equal(fiber.StatusOK, status("/debug/pprof/heap"))
+}
@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Indents JSON responses with two spaces when requested with ?pretty=true.
+// Handy when debugging api by hand, responses are compact otherwise.
+func PrettyJSONHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Query("pretty") != "true" {
+			return ctx.Next()
+		}
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+
+		contentType := string(ctx.Response().Header.ContentType())
+		if !strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+			return nil
+		}
+		if len(ctx.Response().Header.Peek(HeaderSignature)) != 0 {
+			// signed body must be served byte for byte
+			return nil
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, ctx.Response().Body(), "", "  "); err != nil {
+			// not our business to fix handler output, serve it as is
+			return nil
+		}
+		ctx.Response().SetBody(indented.Bytes())
+		return nil
+	}
+}
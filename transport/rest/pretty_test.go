@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettyJSONHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New()
+	app.Use(PrettyJSONHandler())
+	app.Get("/json", func(ctx *fiber.Ctx) error {
+		return ctx.JSON(map[string]interface{}{
+			"name":  "ww_makin_c",
+			"roles": []string{"pro"},
+		})
+	})
+	app.Get("/text", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(`{"im":"working"}`)
+	})
+
+	request := func(path string) string {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if !assert.NoError(err) {
+			return ""
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		return string(body)
+	}
+
+	assert.Equal(`{"name":"ww_makin_c","roles":["pro"]}`, request("/json"))
+	assert.Equal(`{"name":"ww_makin_c","roles":["pro"]}`, request("/json?pretty=false"))
+	assert.Equal("{\n  \"name\": \"ww_makin_c\",\n  \"roles\": [\n    \"pro\"\n  ]\n}", request("/json?pretty=true"))
+	// only json responses are indented
+	assert.Equal(`{"im":"working"}`, request("/text?pretty=true"))
+}
@@ -1,7 +1,6 @@
 package rest
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
 	"strconv"
@@ -25,18 +24,17 @@ func (c *ProfileController) serveProfile(ctx *fiber.Ctx) error {
 	if userIdStr == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "no user id")
 	}
-	userId, err := strconv.ParseInt(userIdStr, 10, 0)
+	userId, err := strconv.ParseInt(userIdStr, 10, 64)
 	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return fiber.NewError(fiber.StatusBadRequest, "user id out of range")
+		}
 		return fiber.NewError(fiber.StatusBadRequest, "invalid user id")
 	}
 
-	profile, err := c.Store.ByUserId(ctx.Context(), buzza.UserId(userId))
+	profile, err := c.Store.ByUserId(requestContext(ctx), buzza.UserId(userId))
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return fiber.NewError(fiber.StatusNotFound, "profile not found")
-		} else {
-			return fmt.Errorf("get profile by user id: %w", err)
-		}
+		return fmt.Errorf("get profile by user id: %w", err)
 	}
 
 	type ProfileResponse struct {
@@ -42,3 +42,46 @@ func TestProfileControllerLookup(t *testing.T) {
 	}
 	assert.Equal(`{"name":"ww_makin_c","avatarUrl":"https://buzkaaclicker.pl/avatar/123"}`, string(body))
 }
+
+func TestProfileControllerUserIdParsing(t *testing.T) {
+	assert := assert.New(t)
+
+	controller := ProfileController{
+		Store: mock.ProfileService{
+			ByUserIdFn: func(ctx context.Context, userId buzza.UserId) (buzza.Profile, error) {
+				return buzza.Profile{
+					User:      buzza.User{Id: userId},
+					Name:      "ww_makin_c",
+					AvatarUrl: "https://buzkaaclicker.pl/avatar/123",
+				}, nil
+			},
+		},
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(app)
+
+	cases := []struct {
+		path       string
+		statusCode int
+		body       string
+	}{
+		{"/profile/9223372036854775807", fiber.StatusOK,
+			`{"name":"ww_makin_c","avatarUrl":"https://buzkaaclicker.pl/avatar/123"}`},
+		{"/profile/makin", fiber.StatusBadRequest, JsonErrorMessageResponse("invalid user id")},
+		{"/profile/9223372036854775808", fiber.StatusBadRequest, JsonErrorMessageResponse("user id out of range")},
+		{"/profile/-9223372036854775809", fiber.StatusBadRequest, JsonErrorMessageResponse("user id out of range")},
+	}
+	for _, tc := range cases {
+		resp, err := app.Test(httptest.NewRequest("GET", tc.path, nil))
+		if !assert.NoError(err, tc.path) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if !assert.NoError(err, tc.path) {
+			continue
+		}
+		assert.Equal(tc.statusCode, resp.StatusCode, tc.path)
+		assert.Equal(tc.body, string(body), tc.path)
+	}
+}
@@ -1,19 +1,45 @@
 package rest
 
 import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/buzkaaclicker/buzza"
 	"github.com/gofiber/fiber/v2"
 )
 
+// Platforms change only on releases, so it's fine to serve them slightly stale.
+const platformsCacheTTL = time.Minute
+
+// Returned by platforms load when store has none, so empty results are not cached.
+var errNoPlatforms = errors.New("no platforms")
+
+// Ed25519 signature of response body, base64 encoded.
+const HeaderSignature = "X-Signature"
+
 type ProgramController struct {
 	Store buzza.ProgramStore
+	// Fail instead of serving expired cache when store is unavailable.
+	DisableStaleServe bool
+	// Signs download responses, so clients can detect tampering. Nil disables signing.
+	SigningKey ed25519.PrivateKey
+	// buzza.RealClock outside tests.
+	Clock buzza.Clock
+
+	platformsCache ttlCache
+	staleServes    uint64
 }
 
 func (c *ProgramController) InstallTo(app *fiber.App) {
+	app.Get("/download/pubkey", c.servePublicKey)
 	app.Get("/download/:file_type", c.download)
+	app.Get("/download/:file_type/platforms", c.servePlatforms)
 }
 
 // type, arch, os, branch
@@ -23,13 +49,9 @@ func (c *ProgramController) download(ctx *fiber.Ctx) error {
 	arch := ctx.Query("arch")
 	branch := ctx.Query("branch", "stable")
 
-	files, err := c.Store.LatestProgramFiles(ctx.Context(), fileType, os, arch, branch)
+	files, err := c.Store.LatestProgramFiles(requestContext(ctx), fileType, os, arch, branch)
 	if err != nil {
-		if errors.Is(err, buzza.ErrProgramNotFound) {
-			return fiber.ErrNotFound
-		} else {
-			return fmt.Errorf("repo lastest program files: %w", err)
-		}
+		return fmt.Errorf("repo lastest program files: %w", err)
 	}
 
 	type File struct {
@@ -42,9 +64,81 @@ func (c *ProgramController) download(ctx *fiber.Ctx) error {
 		mapped[i] = File{Path: of.Path, DownloadUrl: of.DownloadUrl, Hash: of.Hash}
 	}
 
-	err = ctx.JSON(mapped)
+	body, err := json.Marshal(mapped)
 	if err != nil {
 		return fmt.Errorf("json serialize: %w", err)
 	}
-	return nil
+	if c.SigningKey != nil {
+		signature := ed25519.Sign(c.SigningKey, body)
+		ctx.Set(HeaderSignature, base64.StdEncoding.EncodeToString(signature))
+	}
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Send(body)
+}
+
+// Publishes key verifying download responses signatures.
+func (c *ProgramController) servePublicKey(ctx *fiber.Ctx) error {
+	if c.SigningKey == nil {
+		return fiber.NewError(fiber.StatusNotFound, "signing disabled")
+	}
+	publicKey := c.SigningKey.Public().(ed25519.PublicKey)
+	return ctx.JSON(map[string]string{
+		"publicKey": base64.StdEncoding.EncodeToString(publicKey),
+	})
+}
+
+func (c *ProgramController) servePlatforms(ctx *fiber.Ctx) error {
+	fileType := ctx.Params("file_type")
+	platforms, stale, err := c.platforms(ctx, fileType)
+	if err != nil {
+		return fmt.Errorf("platforms: %w", err)
+	}
+	if stale {
+		ctx.Set(fiber.HeaderWarning, `110 - "Response is Stale"`)
+	}
+
+	type Platform struct {
+		OS     string `json:"os"`
+		Arch   string `json:"arch"`
+		Branch string `json:"branch"`
+	}
+	mapped := make([]Platform, len(platforms))
+	for i, p := range platforms {
+		mapped[i] = Platform{OS: p.OS, Arch: p.Arch, Branch: p.Branch}
+	}
+	return ctx.JSON(mapped)
+}
+
+// Returns cached platforms if they are fresh. When store fails, expired
+// platforms are served (marked as stale) unless DisableStaleServe is set.
+func (c *ProgramController) platforms(ctx *fiber.Ctx, fileType string) ([]buzza.ProgramPlatform, bool, error) {
+	platforms, stale, err := c.platformsCache.get(requestContext(ctx), fileType, platformsCacheTTL, c.Clock.Now,
+		func(ctx context.Context) (interface{}, error) {
+			platforms, err := c.Store.Platforms(ctx, fileType)
+			if err == nil && len(platforms) == 0 {
+				// file types come from path, caching misses would let arbitrary paths grow the cache
+				return nil, errNoPlatforms
+			}
+			return platforms, err
+		})
+	if errors.Is(err, errNoPlatforms) {
+		return []buzza.ProgramPlatform{}, false, nil
+	}
+	if err != nil {
+		if stale && !c.DisableStaleServe {
+			requestLog(ctx).
+				WithError(err).
+				WithField("stale_serve", true).
+				Warnln("Serving stale platforms.")
+			atomic.AddUint64(&c.staleServes, 1)
+			return platforms.([]buzza.ProgramPlatform), true, nil
+		}
+		return nil, false, fmt.Errorf("repo platforms: %w", err)
+	}
+	return platforms.([]buzza.ProgramPlatform), false, nil
+}
+
+// Number of platforms responses served from expired cache because store failed.
+func (c *ProgramController) StaleServes() uint64 {
+	return atomic.LoadUint64(&c.staleServes)
 }
@@ -2,9 +2,18 @@ package rest
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/buzkaaclicker/buzza"
 	"github.com/buzkaaclicker/buzza/mock"
@@ -33,10 +42,10 @@ func TestDownloadProgram(t *testing.T) {
 		{"/download/clicker?os=macOS&arch=x86-64&branch=stable",
 			`[{"path":"installer.pkg","downloadUrl":"https://buzkaaclicker.pl/sample","hash":"1"}]`,
 			[]buzza.ProgramFile{{Path: "installer.pkg", DownloadUrl: "https://buzkaaclicker.pl/sample", Hash: "1"}}},
-		{"/download/clicker?os=macOS&arch=arm64&branch=stable", `{"error_message":"Not Found"}`, nil},
-		{"/download/clicker?os=macOS&arch=x86-64&branch=unstable", `{"error_message":"Not Found"}`, nil},
-		{"/download/clicker?os=macOSes&arch=x86-64&branch=stable", `{"error_message":"Not Found"}`, nil},
-		{"/download/clicker?os=Windows&arch=x86-64&branch=stable", `{"error_message":"Not Found"}`, nil},
+		{"/download/clicker?os=macOS&arch=arm64&branch=stable", `{"error_message":"program not found"}`, nil},
+		{"/download/clicker?os=macOS&arch=x86-64&branch=unstable", `{"error_message":"program not found"}`, nil},
+		{"/download/clicker?os=macOSes&arch=x86-64&branch=stable", `{"error_message":"program not found"}`, nil},
+		{"/download/clicker?os=Windows&arch=x86-64&branch=stable", `{"error_message":"program not found"}`, nil},
 		{"/download/installer?os=Windows&arch=x86-64&branch=stable",
 			`[{"path":"installer.pkg","downloadUrl":"https://buzkaaclicker.pl/sample","hash":"256"}]`,
 			[]buzza.ProgramFile{{Path: "installer.pkg", DownloadUrl: "https://buzkaaclicker.pl/sample", Hash: "256"}}},
@@ -62,3 +71,269 @@ func TestDownloadProgram(t *testing.T) {
 		assert.Equal(tc.body, string(body), "Response body not equal")
 	}
 }
+
+func TestProgramPlatforms(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	programStore := mock.ProgramStore{
+		PlatformsFn: func(ctx context.Context, fileType string) ([]buzza.ProgramPlatform, error) {
+			calls++
+			if fileType != "installer" {
+				return []buzza.ProgramPlatform{}, nil
+			}
+			return []buzza.ProgramPlatform{
+				{OS: "Windows", Arch: "x86-64", Branch: "stable"},
+				{OS: "macOS", Arch: "arm64", Branch: "beta"},
+			}, nil
+		},
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := ProgramController{
+		Store: &programStore,
+		Clock: buzza.RealClock{},
+	}
+	controller.InstallTo(app)
+
+	request := func(path string) string {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if !assert.NoError(err) {
+			return ""
+		}
+		defer resp.Body.Close()
+		assert.Equal(fiber.StatusOK, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		return string(body)
+	}
+
+	expected := `[{"os":"Windows","arch":"x86-64","branch":"stable"},{"os":"macOS","arch":"arm64","branch":"beta"}]`
+	assert.Equal(expected, request("/download/installer/platforms"))
+	assert.Equal(expected, request("/download/installer/platforms"))
+	assert.Equal(1, calls, "platforms should be cached")
+
+	// file types come straight from store, type without programs just has no platforms
+	assert.Equal(`[]`, request("/download/agent/platforms"))
+	assert.Equal(`[]`, request("/download/agent/platforms"))
+	assert.Equal(3, calls, "empty platforms should not be cached")
+	assert.Len(controller.platformsCache.entries, 1)
+}
+
+func TestProgramPlatformsStoreUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	var storeErr error
+	programStore := mock.ProgramStore{
+		PlatformsFn: func(ctx context.Context, fileType string) ([]buzza.ProgramPlatform, error) {
+			if storeErr != nil {
+				return nil, storeErr
+			}
+			return []buzza.ProgramPlatform{{OS: "Windows", Arch: "x86-64", Branch: "stable"}}, nil
+		},
+	}
+
+	for _, disableStaleServe := range []bool{false, true} {
+		storeErr = nil
+		clock := mock.NewClock(time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC))
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		controller := ProgramController{
+			Store:             &programStore,
+			DisableStaleServe: disableStaleServe,
+			Clock:             clock,
+		}
+		controller.InstallTo(app)
+		cacheMetrics := CacheMetricsController{Programs: &controller}
+		cacheMetrics.InstallTo(app)
+
+		request := func(path string) (*http.Response, string) {
+			resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+			if !assert.NoError(err) {
+				return nil, ""
+			}
+			defer resp.Body.Close()
+			body, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(err)
+			return resp, string(body)
+		}
+
+		storeErr = errors.New("connection refused")
+		// nothing cached
+		resp, body := request("/download/installer/platforms")
+		assert.Equal(fiber.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(JsonErrorMessageResponse(fiber.ErrInternalServerError.Message), body)
+
+		storeErr = nil
+		resp, _ = request("/download/installer/platforms")
+		assert.Equal(fiber.StatusOK, resp.StatusCode)
+
+		// expire cache and break store
+		clock.Advance(platformsCacheTTL)
+		storeErr = errors.New("connection refused")
+
+		resp, body = request("/download/installer/platforms")
+		if disableStaleServe {
+			assert.Equal(fiber.StatusInternalServerError, resp.StatusCode)
+			assert.Empty(resp.Header.Get(fiber.HeaderWarning))
+		} else {
+			assert.Equal(fiber.StatusOK, resp.StatusCode)
+			assert.Equal(`110 - "Response is Stale"`, resp.Header.Get(fiber.HeaderWarning))
+			assert.Equal(`[{"os":"Windows","arch":"x86-64","branch":"stable"}]`, body)
+		}
+
+		expectedStaleServes := 1
+		if disableStaleServe {
+			expectedStaleServes = 0
+		}
+		_, body = request("/metrics/cache")
+		assert.Equal(fmt.Sprintf(`{"platforms":{"staleServes":%d}}`, expectedStaleServes), body)
+	}
+}
+
+func TestProgramPlatformsCoalescesCacheMisses(t *testing.T) {
+	assert := assert.New(t)
+
+	const requests = 20
+	var calls int32
+	release := make(chan struct{})
+	programStore := mock.ProgramStore{
+		PlatformsFn: func(ctx context.Context, fileType string) ([]buzza.ProgramPlatform, error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return []buzza.ProgramPlatform{{OS: "Windows", Arch: "x86-64", Branch: "stable"}}, nil
+		},
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := ProgramController{
+		Store: &programStore,
+		Clock: buzza.RealClock{},
+	}
+	controller.InstallTo(app)
+
+	var wg sync.WaitGroup
+	statusCodes := make([]int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := app.Test(httptest.NewRequest("GET", "/download/installer/platforms", nil), -1)
+			if assert.NoError(err) {
+				statusCodes[i] = resp.StatusCode
+			}
+		}(i)
+	}
+	// let requests pile up on the in-flight query
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(int32(1), atomic.LoadInt32(&calls))
+	for _, statusCode := range statusCodes {
+		assert.Equal(fiber.StatusOK, statusCode)
+	}
+}
+
+func TestDownloadProgramSigned(t *testing.T) {
+	assert := assert.New(t)
+
+	_, signingKey, err := ed25519.GenerateKey(nil)
+	if !assert.NoError(err) {
+		return
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := ProgramController{
+		Store: mock.ProgramStore{
+			LatestProgramFilesFn: func(ctx context.Context,
+				fileType string, os string, arch string, branch string) ([]buzza.ProgramFile, error) {
+				return []buzza.ProgramFile{{Path: "installer.pkg", DownloadUrl: "https://buzkaaclicker.pl/sample", Hash: "499"}}, nil
+			},
+		},
+		SigningKey: signingKey,
+	}
+	controller.InstallTo(app)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/download/pubkey", nil))
+	if !assert.NoError(err) {
+		return
+	}
+	pubkeyResponse := struct {
+		PublicKey string `json:"publicKey"`
+	}{}
+	if !assert.NoError(json.NewDecoder(resp.Body).Decode(&pubkeyResponse)) {
+		return
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(pubkeyResponse.PublicKey)
+	if !assert.NoError(err) {
+		return
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/download/installer?os=macOS&arch=x86-64", nil))
+	if !assert.NoError(err) {
+		return
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(err)
+	assert.Equal(`[{"path":"installer.pkg","downloadUrl":"https://buzkaaclicker.pl/sample","hash":"499"}]`, string(body))
+	signature, err := base64.StdEncoding.DecodeString(resp.Header.Get(HeaderSignature))
+	assert.NoError(err)
+	assert.True(ed25519.Verify(publicKey, body, signature), "signature does not verify against published key")
+	assert.False(ed25519.Verify(publicKey, append(body, ' '), signature), "tampered body verified")
+}
+
+func TestDownloadProgramUnsigned(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := ProgramController{
+		Store: mock.ProgramStore{
+			LatestProgramFilesFn: func(ctx context.Context,
+				fileType string, os string, arch string, branch string) ([]buzza.ProgramFile, error) {
+				return []buzza.ProgramFile{}, nil
+			},
+		},
+	}
+	controller.InstallTo(app)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/download/installer?os=macOS&arch=x86-64", nil))
+	if assert.NoError(err) {
+		assert.Empty(resp.Header.Get(HeaderSignature))
+	}
+	resp, err = app.Test(httptest.NewRequest("GET", "/download/pubkey", nil))
+	if assert.NoError(err) {
+		assert.Equal(fiber.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestProgramPlatformsCacheExpiry(t *testing.T) {
+	assert := assert.New(t)
+
+	calls := 0
+	programStore := mock.ProgramStore{
+		PlatformsFn: func(ctx context.Context, fileType string) ([]buzza.ProgramPlatform, error) {
+			calls++
+			return []buzza.ProgramPlatform{{OS: "Windows", Arch: "x86-64", Branch: "stable"}}, nil
+		},
+	}
+	clock := mock.NewClock(time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC))
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller := ProgramController{
+		Store: &programStore,
+		Clock: clock,
+	}
+	controller.InstallTo(app)
+
+	request := func() {
+		resp, err := app.Test(httptest.NewRequest("GET", "/download/installer/platforms", nil))
+		if assert.NoError(err) {
+			assert.Equal(fiber.StatusOK, resp.StatusCode)
+		}
+	}
+
+	request()
+	assert.Equal(1, calls)
+	clock.Advance(platformsCacheTTL - time.Second)
+	request()
+	assert.Equal(1, calls, "platforms should be cached until ttl passes")
+	clock.Advance(time.Second)
+	request()
+	assert.Equal(2, calls, "expired platforms should be queried again")
+}
@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"fmt"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProgramAdminController struct {
+	Store buzza.ProgramStore
+	// Zero means buzza.PromotionClone.
+	Mode buzza.PromotionMode
+}
+
+func (c *ProgramAdminController) InstallTo(requestAuthorizer fiber.Handler, app *fiber.App) {
+	authorize := combineHandlers(requestAuthorizer, requirePermissions(buzza.PermissionAdminDashboard))
+	app.Post("/admin/program/:id/promote", combineHandlers(authorize, c.servePromote))
+}
+
+func (c *ProgramAdminController) servePromote(ctx *fiber.Ctx) error {
+	programId, err := ctx.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid program id")
+	}
+	body := struct {
+		Branch string `json:"branch"`
+	}{}
+	if err := ctx.BodyParser(&body); err != nil || body.Branch == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid body")
+	}
+
+	user, ok := ctx.Locals(userLocalsKey).(buzza.User)
+	if !ok {
+		return fiber.ErrUnauthorized
+	}
+
+	mode := c.Mode
+	if mode == "" {
+		mode = buzza.PromotionClone
+	}
+	program, err := c.Store.Promote(requestContext(ctx), programId, body.Branch, mode, user.Id)
+	if err != nil {
+		return fmt.Errorf("repo promote: %w", err)
+	}
+	requestLog(ctx).
+		WithField("source_program_id", programId).
+		WithField("program_id", program.Id).
+		WithField("branch", program.Branch).
+		WithField("mode", mode).
+		Infoln("Program promoted.")
+
+	return ctx.JSON(map[string]interface{}{
+		"id":     program.Id,
+		"type":   program.Type,
+		"os":     program.OS,
+		"arch":   program.Arch,
+		"branch": program.Branch,
+	})
+}
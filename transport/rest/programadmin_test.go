@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/buzkaaclicker/buzza/mock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromoteProgram(t *testing.T) {
+	assert := assert.New(t)
+
+	programs := map[int]buzza.Program{
+		7: {Id: 7, Type: "installer", OS: "Windows", Arch: "x86-64", Branch: "beta"},
+	}
+	var promotionModes []buzza.PromotionMode
+	var promotedBy []buzza.UserId
+	programStore := mock.ProgramStore{
+		PromoteFn: func(ctx context.Context, programId int, branch string, mode buzza.PromotionMode,
+			userId buzza.UserId) (buzza.Program, error) {
+			promotionModes = append(promotionModes, mode)
+			promotedBy = append(promotedBy, userId)
+			source, ok := programs[programId]
+			if !ok {
+				return buzza.Program{}, buzza.ErrProgramNotFound
+			}
+			if err := buzza.ValidatePromotion(source.Branch, branch); err != nil {
+				return buzza.Program{}, err
+			}
+			promoted := source
+			promoted.Id = 8
+			promoted.Branch = branch
+			return promoted, nil
+		},
+	}
+	admin := buzza.User{Id: 1, Roles: buzza.Roles{buzza.AllRoles[buzza.RoleIdAdmin]}}
+	controller := ProgramAdminController{Store: programStore}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(func(ctx *fiber.Ctx) error {
+		ctx.Locals(userLocalsKey, admin)
+		return nil
+	}, app)
+
+	cases := []struct {
+		path         string
+		body         string
+		expectedCode int
+		expectedBody string
+	}{
+		{"/admin/program/7/promote", `{"branch":"stable"}`, fiber.StatusOK,
+			`{"arch":"x86-64","branch":"stable","id":8,"os":"Windows","type":"installer"}`},
+		{"/admin/program/7/promote", `{"branch":"nightly"}`, fiber.StatusBadRequest,
+			JsonErrorMessageResponse(buzza.ErrUnknownBranch.Error())},
+		{"/admin/program/7/promote", `{"branch":"alpha"}`, fiber.StatusBadRequest,
+			JsonErrorMessageResponse(buzza.ErrInvalidPromotion.Error())},
+		{"/admin/program/7/promote", `{}`, fiber.StatusBadRequest, JsonErrorMessageResponse("invalid body")},
+		{"/admin/program/9/promote", `{"branch":"stable"}`, fiber.StatusNotFound, JsonErrorMessageResponse("program not found")},
+		{"/admin/program/seven/promote", `{"branch":"stable"}`, fiber.StatusBadRequest,
+			JsonErrorMessageResponse("invalid program id")},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", c.path, bytes.NewBufferString(c.body))
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		resp, err := app.Test(req)
+		if !assert.NoError(err, c.body) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err, c.body)
+		assert.Equal(c.expectedCode, resp.StatusCode, c.body)
+		assert.Equal(c.expectedBody, string(body), c.body)
+	}
+
+	for _, userId := range promotedBy {
+		assert.Equal(admin.Id, userId, "promotion is recorded as promoting admin's")
+	}
+	assert.Contains(promotionModes, buzza.PromotionClone, "clone is default mode")
+	assert.NotContains(promotionModes, buzza.PromotionMove)
+}
+
+func TestPromoteProgramStoreFailure(t *testing.T) {
+	assert := assert.New(t)
+
+	var promotionMode buzza.PromotionMode
+	programStore := mock.ProgramStore{
+		PromoteFn: func(ctx context.Context, programId int, branch string, mode buzza.PromotionMode,
+			promotedBy buzza.UserId) (buzza.Program, error) {
+			promotionMode = mode
+			return buzza.Program{}, errors.New("insert activity: db down")
+		},
+	}
+	admin := buzza.User{Id: 1, Roles: buzza.Roles{buzza.AllRoles[buzza.RoleIdAdmin]}}
+	controller := ProgramAdminController{Store: programStore, Mode: buzza.PromotionMove}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(func(ctx *fiber.Ctx) error {
+		ctx.Locals(userLocalsKey, admin)
+		return nil
+	}, app)
+
+	req := httptest.NewRequest("POST", "/admin/program/7/promote", bytes.NewBufferString(`{"branch":"stable"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if assert.NoError(err) {
+		// promotion and its audit entry are one tx, so failure of either fails request
+		assert.Equal(fiber.StatusInternalServerError, resp.StatusCode)
+	}
+	assert.Equal(buzza.PromotionMove, promotionMode)
+}
+
+func TestPromoteProgramWithoutUser(t *testing.T) {
+	assert := assert.New(t)
+
+	programStore := mock.ProgramStore{
+		PromoteFn: func(ctx context.Context, programId int, branch string, mode buzza.PromotionMode,
+			promotedBy buzza.UserId) (buzza.Program, error) {
+			t.Fatal("promote called without user")
+			return buzza.Program{}, nil
+		},
+	}
+	controller := ProgramAdminController{Store: programStore}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Post("/admin/program/:id/promote", controller.servePromote)
+
+	req := httptest.NewRequest("POST", "/admin/program/7/promote", bytes.NewBufferString(`{"branch":"stable"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	if assert.NoError(err) {
+		assert.Equal(fiber.StatusUnauthorized, resp.StatusCode)
+	}
+}
@@ -0,0 +1,41 @@
+package rest
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type panicResponse struct {
+	ErrorResponse
+	Stack string `json:"stack"`
+}
+
+// Recovers from panics in handlers. Stack is always logged, but replied to client only
+// when includeStack is set. Never enable it in production, stack leaks internals.
+func RecoverHandler(includeStack bool) fiber.Handler {
+	return func(ctx *fiber.Ctx) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			stack := string(debug.Stack())
+			requestLog(ctx).
+				WithField("panic", r).
+				WithField("stack", stack).
+				Errorln("Recovered from panic.")
+
+			if !includeStack {
+				err = fiber.ErrInternalServerError
+				return
+			}
+			err = ctx.Status(fiber.StatusInternalServerError).JSON(&panicResponse{
+				ErrorResponse: ErrorResponse{ErrorMessage: fmt.Sprint(r)},
+				Stack:         stack,
+			})
+		}()
+		return ctx.Next()
+	}
+}
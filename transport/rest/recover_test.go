@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	request := func(includeStack bool) (int, string) {
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Use(RecoverHandler(includeStack))
+		app.Get("/panic", func(ctx *fiber.Ctx) error {
+			panic("session store is nil")
+		})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/panic", nil))
+		if !assert.NoError(err) {
+			return 0, ""
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := request(false)
+	assert.Equal(fiber.StatusInternalServerError, status)
+	assert.Equal(JsonErrorMessageResponse(fiber.ErrInternalServerError.Message), body)
+
+	status, body = request(true)
+	assert.Equal(fiber.StatusInternalServerError, status)
+	response := struct {
+		ErrorMessage string `json:"error_message"`
+		Stack        string `json:"stack"`
+	}{}
+	if assert.NoError(json.Unmarshal([]byte(body), &response)) {
+		assert.Equal("session store is nil", response.ErrorMessage)
+		assert.Contains(response.Stack, "TestRecoverHandler")
+	}
+}
@@ -0,0 +1,52 @@
+package rest
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+)
+
+const requestIdLocalsKey = "request_id"
+
+// Tags request with id taken from X-Request-Id header or generated one. Id is
+// echoed in response and attached to every log made through requestLog or
+// buzza.LogFromContext(requestContext(ctx)), stores included.
+func RequestIdHandler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		requestId := ctx.Get(fiber.HeaderXRequestID)
+		if !validRequestId(requestId) {
+			var err error
+			requestId, err = generateRequestId()
+			if err != nil {
+				return err
+			}
+		}
+		ctx.Set(fiber.HeaderXRequestID, requestId)
+		ctx.Locals(requestIdLocalsKey, requestId)
+		ctx.Locals(requestContextLocalsKey, buzza.ContextWithLog(requestContext(ctx), requestLog(ctx)))
+		return ctx.Next()
+	}
+}
+
+// Accepts only short printable ids, so clients can't stuff logs with garbage.
+func validRequestId(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func generateRequestId() (string, error) {
+	id := make([]byte, 16)
+	if _, err := crand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/buzkaaclicker/buzza/mock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIdHandler(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	store := mock.ProfileService{
+		ByUserIdFn: func(ctx context.Context, userId buzza.UserId) (buzza.Profile, error) {
+			buzza.LogFromContext(ctx).Infoln("Querying profile.")
+			return buzza.Profile{Name: "ww_makin_c"}, nil
+		},
+	}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(RequestIdHandler())
+	app.Use(RequestTimeoutHandler(time.Second))
+	controller := ProfileController{Store: store}
+	controller.InstallTo(app)
+
+	request := func(requestId string) string {
+		logHook.Reset()
+		req := httptest.NewRequest("GET", "/profile/12", nil)
+		req.Header.Set(fiber.HeaderXRequestID, requestId)
+		req.Header.Set(HeaderRequestTimeout, "500")
+		resp, err := app.Test(req)
+		if !assert.NoError(err) {
+			return ""
+		}
+		responseId := resp.Header.Get(fiber.HeaderXRequestID)
+		if entry := logHook.LastEntry(); assert.NotNil(entry) {
+			assert.Equal("Querying profile.", entry.Message)
+			assert.Equal(responseId, entry.Data["request_id"], "store log has no request id")
+		}
+		return responseId
+	}
+
+	assert.Equal("client-id-2137", request("client-id-2137"))
+
+	generated := request("")
+	assert.Len(generated, 32)
+	assert.NotEqual(generated, request(""), "generated ids should be unique")
+
+	assert.Len(request("with spaces \n and newline"), 32, "invalid id should be replaced")
+}
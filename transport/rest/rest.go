@@ -2,18 +2,50 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
 
+	"github.com/buzkaaclicker/buzza"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/sirupsen/logrus"
 )
 
+// Sent with unavailable domain errors, which don't know themselves when dependency comes back.
+const defaultUnavailableRetryAfter = 5 * time.Second
+
 type ErrorResponse struct {
 	ErrorMessage interface{} `json:"error_message"`
+	// Always matches Retry-After header.
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
+}
+
+// Error telling client when it may retry. ErrorHandler replies with both
+// Retry-After header and retry_after_seconds, use it for every 429 and 503.
+type RetryAfterError struct {
+	Code       int
+	Message    string
+	RetryAfter time.Duration
+}
+
+func NewRetryAfterError(code int, message string, retryAfter time.Duration) *RetryAfterError {
+	return &RetryAfterError{Code: code, Message: message, RetryAfter: retryAfter}
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Message
 }
 
 func requestLog(ctx *fiber.Ctx) *logrus.Entry {
-	return logrus.
+	log := logrus.NewEntry(logrus.StandardLogger())
+	if requestId, ok := ctx.Locals(requestIdLocalsKey).(string); ok {
+		log = log.WithField("request_id", requestId)
+	}
+	return log.
 		WithField("remote_addr", ctx.Context().RemoteAddr()).
+		WithField("client_ip", clientIP(ctx)).
 		WithField("path", ctx.Path()).
 		WithField("z_referer", string(ctx.Request().Header.Peek("Referer"))).
 		WithField("z_user_agent", string(ctx.Request().Header.Peek("User-Agent"))).
@@ -21,10 +53,27 @@ func requestLog(ctx *fiber.Ctx) *logrus.Entry {
 }
 
 func ErrorHandler(ctx *fiber.Ctx, err error) error {
+	var re *RetryAfterError
+	if errors.As(err, &re) {
+		seconds := int(math.Ceil(re.RetryAfter.Seconds()))
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+		return ctx.
+			Status(re.Code).
+			JSON(&ErrorResponse{ErrorMessage: re.Message, RetryAfterSeconds: seconds})
+	}
 	if fe, ok := err.(*fiber.Error); ok {
 		return ctx.
 			Status(fe.Code).
 			JSON(&ErrorResponse{ErrorMessage: fe.Message})
+	} else if status := statusFromError(err); status != fiber.StatusInternalServerError {
+		message := domainErrorMessage(err, status)
+		if status == fiber.StatusServiceUnavailable {
+			// without the hint clients retry immediately and keep hammering dependency that's down
+			return ErrorHandler(ctx, NewRetryAfterError(status, message, defaultUnavailableRetryAfter))
+		}
+		return ctx.
+			Status(status).
+			JSON(&ErrorResponse{ErrorMessage: message})
 	} else {
 		requestLog(ctx).WithError(err).Errorln("Internal server error.")
 		// keep internal server errors private. reply with generic error message.
@@ -34,6 +83,34 @@ func ErrorHandler(ctx *fiber.Ctx, err error) error {
 	}
 }
 
+// Maps domain error categories to http statuses, anything else is internal server error.
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, buzza.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, buzza.ErrValidation):
+		return fiber.StatusBadRequest
+	case errors.Is(err, buzza.ErrConflict):
+		return fiber.StatusConflict
+	case errors.Is(err, buzza.ErrUnavailable):
+		return fiber.StatusServiceUnavailable
+	case errors.Is(err, buzza.ErrTimeout):
+		return fiber.StatusGatewayTimeout
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Wrapping context added by handlers or stores is never shown to clients,
+// only message of the domain error itself.
+func domainErrorMessage(err error, status int) string {
+	var domainErr *buzza.Error
+	if errors.As(err, &domainErr) {
+		return domainErr.Message
+	}
+	return utils.StatusMessage(status)
+}
+
 func NotFoundHandler(c *fiber.Ctx) error {
 	return fiber.NewError(fiber.StatusNotFound)
 }
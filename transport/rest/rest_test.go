@@ -1,11 +1,17 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/buzkaaclicker/buzza"
 	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +51,119 @@ func TestNotFoundHandler(t *testing.T) {
 		assert.Equal(useCase.returnBody, string(body), assertMsg)
 	}
 }
+
+func TestNotFoundHandlerMounted(t *testing.T) {
+	assert := assert.New(t)
+	logHook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	server := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	server.Use(LogHandler())
+	api := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	api.Get("/home", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(`{"im":"working"}`)
+	})
+	server.Mount("/api/", api)
+	server.Use(NotFoundHandler)
+
+	for _, path := range []string{"/unknown_path", "/api/unknown_path"} {
+		logHook.Reset()
+		resp, err := server.Test(httptest.NewRequest("GET", path, nil))
+		if !assert.NoError(err, path) {
+			continue
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err, path)
+		assert.Equal(fiber.StatusNotFound, resp.StatusCode, path)
+		assert.Equal(JsonErrorMessageResponse("Not Found"), string(body), path)
+		if entry := logHook.LastEntry(); assert.NotNil(entry, path) {
+			assert.Equal(path, entry.Data["path"])
+		}
+	}
+}
+
+func TestErrorHandlerRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Get("/maintenance", func(ctx *fiber.Ctx) error {
+		return NewRetryAfterError(fiber.StatusServiceUnavailable, "maintenance", time.Minute)
+	})
+	app.Get("/busy", func(ctx *fiber.Ctx) error {
+		// sub-second hints are rounded up, never tell client to retry immediately
+		err := NewRetryAfterError(fiber.StatusTooManyRequests, "too many requests", 1500*time.Millisecond)
+		return fmt.Errorf("limiter: %w", err)
+	})
+	app.Get("/unavailable", func(ctx *fiber.Ctx) error {
+		err := buzza.NewError(buzza.ErrUnavailable, "database unavailable")
+		return fmt.Errorf("store: %w", err)
+	})
+
+	cases := []struct {
+		path         string
+		expectedCode int
+		retryAfter   string
+		expectedBody string
+	}{
+		{"/maintenance", fiber.StatusServiceUnavailable, "60",
+			`{"error_message":"maintenance","retry_after_seconds":60}`},
+		{"/busy", fiber.StatusTooManyRequests, "2",
+			`{"error_message":"too many requests","retry_after_seconds":2}`},
+		{"/unavailable", fiber.StatusServiceUnavailable, "5",
+			`{"error_message":"database unavailable","retry_after_seconds":5}`},
+	}
+	for _, c := range cases {
+		resp, err := app.Test(httptest.NewRequest("GET", c.path, nil))
+		if !assert.NoError(err, c.path) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err, c.path)
+		assert.Equal(c.expectedCode, resp.StatusCode, c.path)
+		assert.Equal(c.retryAfter, resp.Header.Get(fiber.HeaderRetryAfter), c.path)
+		assert.Equal(c.expectedBody, string(body), c.path)
+	}
+}
+
+func TestErrorHandlerDomainErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		err          error
+		expectedCode int
+		expectedBody string
+	}{
+		{buzza.ErrNotFound, fiber.StatusNotFound, JsonErrorMessageResponse("Not Found")},
+		{buzza.ErrValidation, fiber.StatusBadRequest, JsonErrorMessageResponse("Bad Request")},
+		{buzza.ErrConflict, fiber.StatusConflict, JsonErrorMessageResponse("Conflict")},
+		{buzza.ErrUnavailable, fiber.StatusServiceUnavailable,
+			`{"error_message":"Service Unavailable","retry_after_seconds":5}`},
+		{buzza.ErrTimeout, fiber.StatusGatewayTimeout, JsonErrorMessageResponse("Gateway Timeout")},
+		{fmt.Errorf("repo promote: %w", buzza.ErrProgramNotFound), fiber.StatusNotFound,
+			JsonErrorMessageResponse("program not found")},
+		{buzza.ErrInvalidPromotion, fiber.StatusBadRequest,
+			JsonErrorMessageResponse("program can be promoted only to more stable branch")},
+		{buzza.NewError(buzza.ErrConflict, "name taken"), fiber.StatusConflict,
+			JsonErrorMessageResponse("name taken")},
+		{errors.New("db on fire"), fiber.StatusInternalServerError,
+			JsonErrorMessageResponse("Internal Server Error")},
+	}
+	for _, c := range cases {
+		assert.Equal(c.expectedCode, statusFromError(c.err), c.err.Error())
+
+		domainErr := c.err
+		app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+		app.Get("/", func(ctx *fiber.Ctx) error {
+			return domainErr
+		})
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if !assert.NoError(err, c.err.Error()) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err, c.err.Error())
+		assert.Equal(c.expectedCode, resp.StatusCode, c.err.Error())
+		assert.Equal(c.expectedBody, string(body), c.err.Error())
+	}
+}
@@ -115,7 +115,7 @@ func RequestAuthorizer(sessionStore buzza.SessionStore, userStore buzza.UserStor
 		}
 		token := strings.TrimPrefix(auth, "Bearer ")
 
-		session, err := sessionStore.AcquireAndRefresh(ctx.Context(), token, ctx.IP(),
+		session, err := sessionStore.AcquireAndRefresh(requestContext(ctx), token, clientIP(ctx),
 			string(ctx.Request().Header.UserAgent()))
 		if err != nil {
 			if errors.Is(err, buzza.ErrSessionNotFound) {
@@ -124,7 +124,7 @@ func RequestAuthorizer(sessionStore buzza.SessionStore, userStore buzza.UserStor
 				return fmt.Errorf("acquire and refresh session: %s", err)
 			}
 		}
-		user, err := userStore.ById(ctx.Context(), session.UserId)
+		user, err := userStore.ById(requestContext(ctx), session.UserId)
 		if err != nil {
 			return fmt.Errorf("retrieve user by id: %s", err)
 		}
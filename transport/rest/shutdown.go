@@ -0,0 +1,29 @@
+package rest
+
+import (
+	"sync"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ShutdownController struct {
+	// Starts graceful shutdown. Called at most once.
+	Shutdown func()
+
+	once sync.Once
+}
+
+func (c *ShutdownController) InstallTo(requestAuthorizer fiber.Handler, app *fiber.App) {
+	app.Post("/admin/shutdown", combineHandlers(requestAuthorizer,
+		requirePermissions(buzza.PermissionAdminDashboard), c.serveShutdown))
+}
+
+func (c *ShutdownController) serveShutdown(ctx *fiber.Ctx) error {
+	c.once.Do(func() {
+		requestLog(ctx).Warnln("Shutdown requested by admin.")
+		// shutdown drains requests, so it can't block this one
+		go c.Shutdown()
+	})
+	return ctx.SendStatus(fiber.StatusAccepted)
+}
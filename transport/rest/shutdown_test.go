@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/buzkaaclicker/buzza"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownController(t *testing.T) {
+	assert := assert.New(t)
+
+	shutdowns := make(chan struct{}, 2)
+	controller := ShutdownController{
+		Shutdown: func() {
+			shutdowns <- struct{}{}
+		},
+	}
+
+	user := buzza.User{Id: 1}
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	controller.InstallTo(func(ctx *fiber.Ctx) error {
+		ctx.Locals(userLocalsKey, user)
+		return nil
+	}, app)
+
+	request := func() int {
+		resp, err := app.Test(httptest.NewRequest("POST", "/admin/shutdown", nil))
+		if !assert.NoError(err) {
+			return 0
+		}
+		return resp.StatusCode
+	}
+
+	assert.Equal(fiber.StatusUnauthorized, request())
+	select {
+	case <-shutdowns:
+		assert.Fail("unprivileged user started shutdown")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	user.Roles = buzza.Roles{buzza.AllRoles[buzza.RoleIdAdmin]}
+	assert.Equal(fiber.StatusAccepted, request())
+	select {
+	case <-shutdowns:
+	case <-time.After(time.Second):
+		assert.Fail("shutdown not started")
+	}
+
+	// second call is accepted but does not start shutdown again
+	assert.Equal(fiber.StatusAccepted, request())
+	select {
+	case <-shutdowns:
+		assert.Fail("shutdown started twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRequestContextCancelledOnShutdown(t *testing.T) {
+	assert := assert.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(err) {
+		return
+	}
+	started := make(chan struct{})
+	handlerErr := make(chan error, 1)
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/long", func(ctx *fiber.Ctx) error {
+		close(started)
+		select {
+		case <-ctx.Context().Done():
+			handlerErr <- ctx.Context().Err()
+		case <-time.After(5 * time.Second):
+			handlerErr <- nil
+		}
+		return nil
+	})
+	go app.Listener(ln)
+
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/long")
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		assert.Fail("long handler not started")
+		return
+	}
+
+	assert.NoError(app.Shutdown())
+	select {
+	case err := <-handlerErr:
+		assert.Error(err, "handler context not cancelled")
+	case <-time.After(time.Second):
+		assert.Fail("handler did not return")
+	}
+}
@@ -0,0 +1,58 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	HeaderRequestTimeout = "X-Request-Timeout"
+
+	requestContextLocalsKey = "request_context"
+)
+
+// Bounds request with timeout (in milliseconds) supplied by caller in X-Request-Timeout header.
+// Malformed values and values above maxTimeout are ignored. Expired requests are answered with 504.
+func RequestTimeoutHandler(maxTimeout time.Duration) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		timeout, ok := parseRequestTimeout(ctx.Get(HeaderRequestTimeout), maxTimeout)
+		if !ok {
+			return ctx.Next()
+		}
+
+		// derived context keeps cancellation on server shutdown and request scoped log.
+		requestCtx, cancel := context.WithTimeout(requestContext(ctx), timeout)
+		defer cancel()
+		ctx.Locals(requestContextLocalsKey, requestCtx)
+
+		err := ctx.Next()
+		if err != nil && errors.Is(requestCtx.Err(), context.DeadlineExceeded) {
+			requestLog(ctx).WithError(err).WithField("timeout", timeout).Warnln("Request timed out.")
+			return fiber.NewError(fiber.StatusGatewayTimeout, "request timeout")
+		}
+		return err
+	}
+}
+
+// Context passed down to stores. Use it instead of ctx.Context().
+func requestContext(ctx *fiber.Ctx) context.Context {
+	if requestCtx, ok := ctx.Locals(requestContextLocalsKey).(context.Context); ok {
+		return requestCtx
+	}
+	return ctx.Context()
+}
+
+func parseRequestTimeout(header string, maxTimeout time.Duration) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	millis, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || millis <= 0 || millis > maxTimeout.Milliseconds() {
+		return 0, false
+	}
+	return time.Duration(millis) * time.Millisecond, true
+}
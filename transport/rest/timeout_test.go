@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimeoutHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(RequestTimeoutHandler(time.Second))
+	app.Get("/slow", func(ctx *fiber.Ctx) error {
+		requestCtx := requestContext(ctx)
+		if _, ok := requestCtx.Deadline(); !ok {
+			return ctx.SendString("no deadline")
+		}
+		select {
+		case <-requestCtx.Done():
+			return fmt.Errorf("slow query: %w", requestCtx.Err())
+		case <-time.After(5 * time.Second):
+			return ctx.SendString("done")
+		}
+	})
+
+	cases := []struct {
+		timeout      string
+		expectedCode int
+		expectedBody string
+	}{
+		{"20", fiber.StatusGatewayTimeout, JsonErrorMessageResponse("request timeout")},
+		{"", fiber.StatusOK, "no deadline"},
+		{"soon", fiber.StatusOK, "no deadline"},
+		{"-20", fiber.StatusOK, "no deadline"},
+		{"5000", fiber.StatusOK, "no deadline"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/slow", nil)
+		req.Header.Set(HeaderRequestTimeout, c.timeout)
+		start := time.Now()
+		resp, err := app.Test(req, 2000)
+		if !assert.NoError(err, c.timeout) {
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(err, c.timeout)
+		assert.Equal(c.expectedCode, resp.StatusCode, c.timeout)
+		assert.Equal(c.expectedBody, string(body), c.timeout)
+		assert.Less(time.Since(start), time.Second, c.timeout)
+	}
+}
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Upper bound of single load, it outlives requests waiting for it.
+const ttlCacheLoadTimeout = 10 * time.Second
+
+// Caches values loaded by key for fixed time. Concurrent misses of the same key share single load.
+// Entries are dropped by invalidate, or when maxEntries is reached and they have expired.
+type ttlCache struct {
+	// Values loaded for new keys past this many entries are returned but not stored. Zero means no limit.
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries map[string]ttlCacheEntry
+	// Bumped by invalidate, loads started before that don't store their results.
+	generation uint64
+	group      singleflight.Group
+}
+
+type ttlCacheEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// Returns value cached under key if it's still fresh, loads and caches it otherwise.
+// Load runs detached from ctx, which only bounds how long caller waits for it.
+// When load fails and expired value is cached, it's returned with stale set along with the error,
+// so caller decides whether serving it is fine.
+func (c *ttlCache) get(ctx context.Context, key string, ttl time.Duration, now func() time.Time,
+	load func(ctx context.Context) (interface{}, error)) (value interface{}, stale bool, err error) {
+	c.mutex.Lock()
+	entry, ok := c.entries[key]
+	generation := c.generation
+	c.mutex.Unlock()
+	if ok && now().Before(entry.expiresAt) {
+		return entry.value, false, nil
+	}
+
+	// loads of different generations never coalesce, so requests made after invalidate
+	// don't pick up result of load started before it
+	groupKey := strconv.FormatUint(generation, 10) + "\x00" + key
+	results := c.group.DoChan(groupKey, func() (interface{}, error) {
+		// shared by every waiter, so it can't be cancelled by the one which happened to start it
+		loadCtx, cancel := context.WithTimeout(context.Background(), ttlCacheLoadTimeout)
+		defer cancel()
+		value, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		if c.generation != generation {
+			return value, nil
+		}
+		if c.entries == nil {
+			c.entries = make(map[string]ttlCacheEntry)
+		}
+		if !c.hasRoom(key, now()) {
+			return value, nil
+		}
+		c.entries[key] = ttlCacheEntry{value: value, expiresAt: now().Add(ttl)}
+		return value, nil
+	})
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case result := <-results:
+		value, err = result.Val, result.Err
+	}
+	if err != nil {
+		if ok {
+			return entry.value, true, err
+		}
+		return nil, false, err
+	}
+	return value, false, nil
+}
+
+// Keys come from requests, so once limit is hit only expired entries make room for new ones.
+// Mutex must be held.
+func (c *ttlCache) hasRoom(key string, now time.Time) bool {
+	if _, ok := c.entries[key]; ok || c.maxEntries <= 0 || len(c.entries) < c.maxEntries {
+		return true
+	}
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	return len(c.entries) < c.maxEntries
+}
+
+// Drops every entry, in-flight loads won't store their results.
+func (c *ttlCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = nil
+	c.generation++
+}
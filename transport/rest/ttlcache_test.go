@@ -0,0 +1,141 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/buzkaaclicker/buzza/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLCacheInvalidateDuringLoad(t *testing.T) {
+	assert := assert.New(t)
+
+	var cache ttlCache
+	clock := mock.NewClock(time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loaded := make(chan interface{})
+	go func() {
+		value, _, _ := cache.get(context.Background(), "flags", time.Minute, clock.Now,
+			func(ctx context.Context) (interface{}, error) {
+				close(started)
+				<-release
+				return "before put", nil
+			})
+		loaded <- value
+	}()
+
+	<-started
+	cache.invalidate()
+	close(release)
+	assert.Equal("before put", <-loaded)
+
+	// result of load started before invalidate must not be cached
+	value, stale, err := cache.get(context.Background(), "flags", time.Minute, clock.Now,
+		func(ctx context.Context) (interface{}, error) {
+			return "after put", nil
+		})
+	assert.NoError(err)
+	assert.False(stale)
+	assert.Equal("after put", value)
+}
+
+func TestTTLCacheStale(t *testing.T) {
+	assert := assert.New(t)
+
+	var cache ttlCache
+	clock := mock.NewClock(time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC))
+	var loadErr error
+	load := func(ctx context.Context) (interface{}, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		return "platforms", nil
+	}
+
+	loadErr = errors.New("connection refused")
+	value, stale, err := cache.get(context.Background(), "installer", time.Minute, clock.Now, load)
+	assert.Error(err)
+	assert.False(stale, "nothing cached yet")
+	assert.Nil(value)
+
+	loadErr = nil
+	_, _, err = cache.get(context.Background(), "installer", time.Minute, clock.Now, load)
+	assert.NoError(err)
+
+	clock.Advance(time.Minute)
+	loadErr = errors.New("connection refused")
+	value, stale, err = cache.get(context.Background(), "installer", time.Minute, clock.Now, load)
+	assert.ErrorIs(err, loadErr)
+	assert.True(stale)
+	assert.Equal("platforms", value)
+}
+
+func TestTTLCacheLoadDetachedFromCaller(t *testing.T) {
+	assert := assert.New(t)
+
+	var cache ttlCache
+	clock := mock.NewClock(time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var loadCtxErr error
+	load := func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-release
+		loadCtxErr = ctx.Err()
+		return "flags", nil
+	}
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, _, err := cache.get(firstCtx, "flags", time.Minute, clock.Now, load)
+		firstDone <- err
+	}()
+	<-started
+
+	secondDone := make(chan interface{})
+	go func() {
+		value, _, _ := cache.get(context.Background(), "flags", time.Minute, clock.Now, load)
+		secondDone <- value
+	}()
+
+	// caller which started load gives up, it must not take others down with it
+	cancelFirst()
+	assert.ErrorIs(<-firstDone, context.Canceled)
+	close(release)
+	assert.Equal("flags", <-secondDone)
+	assert.NoError(loadCtxErr)
+}
+
+func TestTTLCacheMaxEntries(t *testing.T) {
+	assert := assert.New(t)
+
+	cache := ttlCache{maxEntries: 2}
+	clock := mock.NewClock(time.Date(2022, 2, 20, 21, 37, 0, 0, time.UTC))
+	load := func(key string) {
+		value, _, err := cache.get(context.Background(), key, time.Minute, clock.Now,
+			func(ctx context.Context) (interface{}, error) {
+				return key, nil
+			})
+		assert.NoError(err, key)
+		assert.Equal(key, value, "value past limit must still be returned")
+	}
+
+	load("a")
+	clock.Advance(30 * time.Second)
+	load("b")
+	load("c")
+	assert.Len(cache.entries, 2)
+	assert.NotContains(cache.entries, "c")
+
+	// expired entries make room for new keys
+	clock.Advance(30 * time.Second)
+	load("c")
+	assert.Len(cache.entries, 2)
+	assert.Contains(cache.entries, "b")
+	assert.Contains(cache.entries, "c")
+}
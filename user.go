@@ -2,13 +2,12 @@ package buzza
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/buzkaaclicker/buzza/discord"
 )
 
-var ErrUserNotFound = errors.New("user not found")
+var ErrUserNotFound = NewError(ErrNotFound, "user not found")
 
 type UserId int64
 